@@ -0,0 +1,92 @@
+package main
+
+import (
+	"code/decimal"
+	"code/models"
+	"encoding/json"
+	"errors"
+	"os"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+func main() {
+	lambda.Start(handler)
+}
+
+/*
+ * メインハンドラー
+ * @param request httpリクエスト
+ * return httpレスポンス
+ */
+func handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	// 変数初期化
+	var allocationData interface{}
+	var err error
+
+	// リクエストがPOSTかGETで実行する処理を分岐する
+	switch request.HTTPMethod {
+	case "POST":
+		// リクエストボディ取得（目標配分の登録）
+		reqBody := request.Body
+		jsonBytes := ([]byte)(reqBody)
+		allocationTargetReq := new(models.AllocationTargetReq)
+		if err := json.Unmarshal(jsonBytes, allocationTargetReq); err != nil {
+			return events.APIGatewayProxyResponse{}, err
+		}
+		err = models.SaveAllocationTarget(allocationTargetReq)
+		if err == nil {
+			allocationData, err = models.GetAllocationTargetList()
+		}
+	case "GET":
+		// クエリパラメータ取得
+		// リバランス方法(full: 売買により目標配分に合わせる, newMoney: 追加資金のみで購入する)、追加資金
+		mode := request.QueryStringParameters["mode"]
+		contribution := decimal.Zero
+		if value := request.QueryStringParameters["contribution"]; value != "" {
+			if contribution, err = decimal.NewFromString(value); err != nil {
+				err = &models.ValidationError{Message: "invalid contribution: " + value}
+			}
+		}
+
+		// 全ての資産購入データと目標配分を取得し、乖離とリバランス案を算出
+		var assetBuyData []models.AssetBuy
+		if err == nil {
+			assetBuyData, err = models.GetAssetBuyByAssetCode("")
+		}
+		var allocationTargetList []models.AllocationTarget
+		if err == nil {
+			allocationTargetList, err = models.GetAllocationTargetList()
+		}
+		if err == nil {
+			allocationData, err = models.CalcRebalancePlan(assetBuyData, allocationTargetList, contribution, mode)
+		}
+	}
+	// 入力内容の誤りはステータス400として返す
+	var validationError *models.ValidationError
+	if errors.As(err, &validationError) {
+		jsonBytes, _ := json.Marshal(validationError)
+		return response(string(jsonBytes), 400), nil
+	}
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	jsonBytes, _ := json.Marshal(allocationData)
+	return response(string(jsonBytes), 200), nil
+}
+
+// httpレスポンスを生成
+func response(body string, statusCode int) events.APIGatewayProxyResponse {
+	return events.APIGatewayProxyResponse{
+		Headers: map[string]string{
+			"Access-Control-Allow-Origin":      os.Getenv("ALLOW_ORIGIN"),
+			"Access-Control-Allow-Headers":     "X-Requested-With, Origin, X-Csrftoken, Content-Type, Accept",
+			"Access-Control-Allow-Credentials": "true",
+			"Content-Type":                     "application/json",
+		},
+		Body:       body,
+		StatusCode: statusCode,
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"code/models"
+	"encoding/json"
+	"errors"
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+func main() {
+	lambda.Start(handler)
+}
+
+/*
+ * メインハンドラー
+ * @param request httpリクエスト
+ * return httpレスポンス
+ */
+func handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	// 変数初期化
+	var correlationMatrix models.CorrelationMatrix
+	var err error
+
+	// クエリパラメータ取得
+	// 遡る日数（指定がない場合は1年）
+	lookbackDays := 365
+	if param := request.QueryStringParameters["lookbackDays"]; param != "" {
+		if lookbackDays, err = strconv.Atoi(param); err != nil {
+			err = &models.ValidationError{Message: "invalid lookbackDays: " + param}
+		}
+	}
+
+	// 全ての資産購入データを取得し、保有資産間の相関行列を算出
+	if err == nil {
+		var assetBuyData []models.AssetBuy
+		assetBuyData, err = models.GetAssetBuyByAssetCode("")
+		if err == nil {
+			correlationMatrix, err = models.CalcCorrelationMatrix(assetBuyData, lookbackDays)
+		}
+	}
+
+	// 入力内容の誤りはステータス400として返す
+	var validationError *models.ValidationError
+	if errors.As(err, &validationError) {
+		jsonBytes, _ := json.Marshal(validationError)
+		return response(string(jsonBytes), 400), nil
+	}
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	jsonBytes, _ := json.Marshal(correlationMatrix)
+	return response(string(jsonBytes), 200), nil
+}
+
+// httpレスポンスを生成
+func response(body string, statusCode int) events.APIGatewayProxyResponse {
+	return events.APIGatewayProxyResponse{
+		Headers: map[string]string{
+			"Access-Control-Allow-Origin":      os.Getenv("ALLOW_ORIGIN"),
+			"Access-Control-Allow-Headers":     "X-Requested-With, Origin, X-Csrftoken, Content-Type, Accept",
+			"Access-Control-Allow-Credentials": "true",
+			"Content-Type":                     "application/json",
+		},
+		Body:       body,
+		StatusCode: statusCode,
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"code/models"
+	"encoding/json"
+	"errors"
+	"os"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+func main() {
+	lambda.Start(handler)
+}
+
+/*
+ * メインハンドラー
+ * @param request httpリクエスト
+ * return httpレスポンス
+ */
+func handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	// 変数初期化
+	var distributionData interface{}
+	var err error
+
+	// リクエストのメソッドで実行する処理を分岐する
+	switch request.HTTPMethod {
+	case "POST":
+		// リクエストボディ取得（配当金・分配金の登録）
+		reqBody := request.Body
+		jsonBytes := ([]byte)(reqBody)
+		assetDistributionReq := new(models.AssetDistributionReq)
+		if err := json.Unmarshal(jsonBytes, assetDistributionReq); err != nil {
+			return events.APIGatewayProxyResponse{}, err
+		}
+		distributionData, err = models.SaveAssetDistribution(assetDistributionReq)
+	case "PUT":
+		// 資産コードと取引IDで特定した配当金・分配金を置き換える
+		assetDistributionReq := new(models.AssetDistributionReq)
+		if err := json.Unmarshal([]byte(request.Body), assetDistributionReq); err != nil {
+			return events.APIGatewayProxyResponse{}, err
+		}
+		distributionData, err = models.UpdateAssetDistribution(request.QueryStringParameters["assetCode"],
+			request.QueryStringParameters["transactionId"], assetDistributionReq)
+	case "PATCH":
+		// 指定された項目のみ変更する
+		assetDistributionPatchReq := new(models.AssetDistributionPatchReq)
+		if err := json.Unmarshal([]byte(request.Body), assetDistributionPatchReq); err != nil {
+			return events.APIGatewayProxyResponse{}, err
+		}
+		distributionData, err = models.PatchAssetDistribution(request.QueryStringParameters["assetCode"],
+			request.QueryStringParameters["transactionId"], assetDistributionPatchReq)
+	case "DELETE":
+		err = models.DeleteAssetDistribution(request.QueryStringParameters["assetCode"], request.QueryStringParameters["transactionId"])
+	case "GET":
+		// クエリパラメータ取得
+		assetCode := request.QueryStringParameters["assetCode"]
+		distributionData, err = models.GetAssetDistributionList(assetCode)
+	}
+	// 入力内容の誤りはステータス400として返す
+	var validationError *models.ValidationError
+	if errors.As(err, &validationError) {
+		jsonBytes, _ := json.Marshal(validationError)
+		return response(string(jsonBytes), 400), nil
+	}
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	jsonBytes, _ := json.Marshal(distributionData)
+	return response(string(jsonBytes), 200), nil
+}
+
+// httpレスポンスを生成
+func response(body string, statusCode int) events.APIGatewayProxyResponse {
+	return events.APIGatewayProxyResponse{
+		Headers: map[string]string{
+			"Access-Control-Allow-Origin":      os.Getenv("ALLOW_ORIGIN"),
+			"Access-Control-Allow-Headers":     "X-Requested-With, Origin, X-Csrftoken, Content-Type, Accept",
+			"Access-Control-Allow-Credentials": "true",
+			"Content-Type":                     "application/json",
+		},
+		Body:       body,
+		StatusCode: statusCode,
+	}
+}
@@ -3,12 +3,16 @@ package main
 import (
 	"code/models"
 	"encoding/json"
+	"errors"
 	"os"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 )
 
+// 資産マスタの検索のリソースパス（一覧とはレスポンスの形式が異なるため、別のリソースとする）
+const searchResource = "/asset-master/search"
+
 func main() {
 	lambda.Start(handler)
 }
@@ -19,12 +23,12 @@ func main() {
  * return httpレスポンス
  */
 func handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	var assetMasterData []models.AssetMaster
+	var responseData interface{}
 	var assetCode string
 	var categoryId string
 	var err error
 
-	// リクエストがPOSTかGETで実行する処理を分岐する
+	// リクエストのメソッドで実行する処理を分岐する
 	switch request.HTTPMethod {
 	case "POST":
 		// リクエストボディ取得
@@ -35,17 +39,58 @@ func handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 			return events.APIGatewayProxyResponse{}, err
 		}
 		err = models.SaveAssetMaster(assetMasterReq)
+	case "PUT":
+		// 資産コードで特定した資産マスタを置き換える（全項目を指定する。省略した項目は既定値に戻る）
+		assetMasterReq := new(models.AssetMasterReq)
+		if err := json.Unmarshal([]byte(request.Body), assetMasterReq); err != nil {
+			return events.APIGatewayProxyResponse{}, err
+		}
+		responseData, err = models.UpdateAssetMaster(assetMasterReq)
+	case "PATCH":
+		// 指定された項目のみ変更する
+		assetMasterPatchReq := new(models.AssetMasterPatchReq)
+		if err := json.Unmarshal([]byte(request.Body), assetMasterPatchReq); err != nil {
+			return events.APIGatewayProxyResponse{}, err
+		}
+		responseData, err = models.PatchAssetMaster(assetMasterPatchReq)
+	case "DELETE":
+		// 取引・価格が登録されている資産は削除できない
+		assetCode = request.QueryStringParameters["assetCode"]
+		err = models.DeleteAssetMaster(assetCode)
 	case "GET":
 		// パス・クエリパラメータ取得
 		assetCode = request.QueryStringParameters["assetCode"]
-		categoryId = request.QueryStringParameters["date"]
-		assetMasterData, err = models.GetAssetMasterByAssetCodeAndCategoryId(assetCode, categoryId)
+		categoryId = request.QueryStringParameters["categoryId"]
+		if request.Resource != searchResource {
+			// 資産マスタの一覧（資産コードの指定がない場合はカテゴリーIDに一致する全件）
+			responseData, err = models.GetAssetMasterByAssetCodeAndCategoryId(assetCode, categoryId)
+			break
+		}
+		// 検索は条件に一致する資産マスタを1ページ分返す
+		responseData, err = models.SearchAssetMaster(models.AssetMasterQuery{
+			CategoryId: categoryId,
+			Type:       request.QueryStringParameters["type"],
+			Name:       request.QueryStringParameters["name"],
+			Limit:      request.QueryStringParameters["limit"],
+			Cursor:     request.QueryStringParameters["cursor"],
+		})
+	}
+	// 入力内容の誤りはステータス400として返す
+	var validationError *models.ValidationError
+	if errors.As(err, &validationError) {
+		jsonBytes, _ := json.Marshal(validationError)
+		return response(string(jsonBytes), 400), nil
 	}
 	if err != nil {
 		return events.APIGatewayProxyResponse{}, err
 	}
 
-	jsonBytes, _ := json.Marshal(assetMasterData)
+	jsonBytes, _ := json.Marshal(responseData)
+	return response(string(jsonBytes), 200), nil
+}
+
+// httpレスポンスを生成
+func response(body string, statusCode int) events.APIGatewayProxyResponse {
 	return events.APIGatewayProxyResponse{
 		Headers: map[string]string{
 			"Access-Control-Allow-Origin":      os.Getenv("ALLOW_ORIGIN"),
@@ -53,7 +98,7 @@ func handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 			"Access-Control-Allow-Credentials": "true",
 			"Content-Type":                     "application/json",
 		},
-		Body:       string(jsonBytes),
-		StatusCode: 200,
-	}, nil
+		Body:       body,
+		StatusCode: statusCode,
+	}
 }
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"code/models"
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+func main() {
+	lambda.Start(handler)
+}
+
+/*
+ * メインハンドラー
+ * @param request httpリクエスト
+ * return httpレスポンス
+ */
+func handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	// 変数初期化
+	var performanceList models.PerformanceList
+	var err error
+
+	// クエリパラメータ取得
+	// 期間(yyyy-mm-dd)
+	fromDate := request.QueryStringParameters["from"]
+	toDate := request.QueryStringParameters["to"]
+	// ベンチマーク(ex: ^GSPC, ^GSPC:0.6,^TPX:0.4)
+	benchmark := request.QueryStringParameters["benchmark"]
+	// 無リスク金利（年率、指定がない場合は0。ベンチマークとのアルファの算出に使用）
+	riskFreeRate := 0.0
+	if param := request.QueryStringParameters["riskFreeRate"]; param != "" {
+		riskFreeRate, err = strconv.ParseFloat(param, 64)
+		if err != nil || math.IsNaN(riskFreeRate) || math.IsInf(riskFreeRate, 0) {
+			err = &models.ValidationError{Message: "invalid riskFreeRate: " + param}
+		}
+	}
+
+	// 全ての資産購入データを取得し、全資産合計・資産毎・カテゴリー毎の収益率とベンチマークとの比較を算出
+	if err == nil {
+		var assetBuyData []models.AssetBuy
+		assetBuyData, err = models.GetAssetBuyByAssetCode("")
+		if err == nil {
+			performanceList, err = models.CalcPerformance(assetBuyData, fromDate, toDate, benchmark, riskFreeRate)
+		}
+	}
+	// 入力内容の誤りはステータス400として返す
+	var validationError *models.ValidationError
+	if errors.As(err, &validationError) {
+		jsonBytes, _ := json.Marshal(validationError)
+		return response(string(jsonBytes), 400), nil
+	}
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	jsonBytes, _ := json.Marshal(performanceList)
+	return response(string(jsonBytes), 200), nil
+}
+
+// httpレスポンスを生成
+func response(body string, statusCode int) events.APIGatewayProxyResponse {
+	return events.APIGatewayProxyResponse{
+		Headers: map[string]string{
+			"Access-Control-Allow-Origin":      os.Getenv("ALLOW_ORIGIN"),
+			"Access-Control-Allow-Headers":     "X-Requested-With, Origin, X-Csrftoken, Content-Type, Accept",
+			"Access-Control-Allow-Credentials": "true",
+			"Content-Type":                     "application/json",
+		},
+		Body:       body,
+		StatusCode: statusCode,
+	}
+}
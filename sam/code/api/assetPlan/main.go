@@ -0,0 +1,86 @@
+package main
+
+import (
+	"code/models"
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+func main() {
+	lambda.Start(handler)
+}
+
+/*
+ * メインハンドラー
+ * @param request httpリクエスト
+ * return httpレスポンス
+ */
+func handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	// 変数初期化
+	var planData interface{}
+	var err error
+
+	// リクエストのメソッドで実行する処理を分岐する
+	switch request.HTTPMethod {
+	case "POST":
+		// リクエストボディ取得（積立プランの登録）
+		reqBody := request.Body
+		jsonBytes := ([]byte)(reqBody)
+		purchasePlanReq := new(models.PurchasePlanReq)
+		if err := json.Unmarshal(jsonBytes, purchasePlanReq); err != nil {
+			return events.APIGatewayProxyResponse{}, err
+		}
+		planData, err = models.SavePurchasePlan(purchasePlanReq)
+	case "PATCH":
+		// 資産コードとプランIDで特定した積立プランの指定された項目のみ変更する（停止・再開を含む）
+		purchasePlanPatchReq := new(models.PurchasePlanPatchReq)
+		if err := json.Unmarshal([]byte(request.Body), purchasePlanPatchReq); err != nil {
+			return events.APIGatewayProxyResponse{}, err
+		}
+		planData, err = models.PatchPurchasePlan(request.QueryStringParameters["assetCode"],
+			request.QueryStringParameters["planId"], purchasePlanPatchReq, today())
+	case "DELETE":
+		err = models.DeletePurchasePlan(request.QueryStringParameters["assetCode"], request.QueryStringParameters["planId"])
+	case "GET":
+		// クエリパラメータ取得
+		assetCode := request.QueryStringParameters["assetCode"]
+		planData, err = models.GetPurchasePlanList(assetCode)
+	}
+	// 入力内容の誤りはステータス400として返す
+	var validationError *models.ValidationError
+	if errors.As(err, &validationError) {
+		jsonBytes, _ := json.Marshal(validationError)
+		return response(string(jsonBytes), 400), nil
+	}
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	jsonBytes, _ := json.Marshal(planData)
+	return response(string(jsonBytes), 200), nil
+}
+
+// 日本時間の当日(yyyy-mm-dd)
+func today() string {
+	jst := time.FixedZone("Asia/Tokyo", 9*60*60)
+	return time.Now().In(jst).Format("2006-01-02")
+}
+
+// httpレスポンスを生成
+func response(body string, statusCode int) events.APIGatewayProxyResponse {
+	return events.APIGatewayProxyResponse{
+		Headers: map[string]string{
+			"Access-Control-Allow-Origin":      os.Getenv("ALLOW_ORIGIN"),
+			"Access-Control-Allow-Headers":     "X-Requested-With, Origin, X-Csrftoken, Content-Type, Accept",
+			"Access-Control-Allow-Credentials": "true",
+			"Content-Type":                     "application/json",
+		},
+		Body:       body,
+		StatusCode: statusCode,
+	}
+}
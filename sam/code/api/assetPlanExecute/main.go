@@ -0,0 +1,65 @@
+package main
+
+import (
+	"code/models"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// 実行結果
+type executionSummary struct {
+	Settlement models.OrderSettlementSummary
+	Plan       models.PlanExecutionSummary
+}
+
+func main() {
+	// Lambda環境以外（ローカル）では、その場で一度だけ実行する
+	// ex) go run ./api/assetPlanExecute -to 2021-05-10
+	if os.Getenv("_LAMBDA_SERVER_PORT") == "" && os.Getenv("AWS_LAMBDA_RUNTIME_API") == "" {
+		toDate := flag.String("to", today(), "登録終了日(yyyy-mm-dd)")
+		flag.Parse()
+		if _, err := execute(*toDate); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	lambda.Start(handler)
+}
+
+/*
+ * メインハンドラー（EventBridgeのスケジュールから実行）
+ * @param event スケジュールイベント
+ * return 実行結果
+ */
+func handler(event events.CloudWatchEvent) (executionSummary, error) {
+	return execute(today())
+}
+
+// 価格が公表された未約定の注文を約定した後、全ての積立プランの注文を登録し、実行結果をログに出力する
+func execute(toDate string) (executionSummary, error) {
+	var summary executionSummary
+	var err error
+	summary.Settlement, err = models.SettleAllAssetOrder()
+	if err != nil {
+		return summary, err
+	}
+	summary.Plan, err = models.ExecuteAllPurchasePlan(toDate)
+	if err != nil {
+		return summary, err
+	}
+	jsonBytes, _ := json.Marshal(summary)
+	log.Printf("order execution summary: %s", jsonBytes)
+	return summary, nil
+}
+
+// 日本時間の当日(yyyy-mm-dd)
+func today() string {
+	jst := time.FixedZone("Asia/Tokyo", 9*60*60)
+	return time.Now().In(jst).Format("2006-01-02")
+}
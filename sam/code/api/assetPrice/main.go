@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"os"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
@@ -21,7 +22,7 @@ func main() {
  */
 func handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	// 変数初期化
-	var assetDailyData []models.AssetDaily
+	var priceData interface{}
 	var err error
 
 	// リクエストがPOSTかGETで実行する処理を分岐する
@@ -34,39 +35,39 @@ func handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 		if err = json.Unmarshal(jsonBytes, assetPriceReq); err != nil {
 			return events.APIGatewayProxyResponse{}, err
 		}
-		// 資産タイプ（株 or 投資信託）
-		assetType := assetPriceReq.AssetType
-		// 資産コード
-		assetCode := assetPriceReq.AssetCode
-		if assetType == "stock" {
-			// 対象地域(ex: US, JP...)
-			region := assetPriceReq.Region
-			// 取得対象期間(1d, 1mo, 1y)
-			getRange := assetPriceReq.GetRange
-			// 株価の時系列データを保存（Yahoo Finance APIから取得）
-			err = models.SavePriceStock(region, assetCode, getRange)
-		} else if assetType == "investmentTrust" {
-			// 取得開始期間(yyyy-mm-dd)
-			fromDate := assetPriceReq.FromDate
-			// 取得終了期間(yyyy-mm-dd)
-			toDate := assetPriceReq.ToDate
-			// 投資信託の基準価格時系列データを保存
-			err = models.SavePriceInvestmentTrust(assetCode, fromDate, toDate)
-		} else {
-			err = errors.New("no entered asset type")
-		}
+		// 資産マスタに設定された取得元から価格の時系列データを取得して保存
+		// 取得期間(yyyy-mm-dd)。旧形式の AssetType, Region, GetRange も受け付ける
+		// 新規・変更・変更なしの件数を返す
+		priceData, err = models.SaveAssetPrice(assetPriceReq, today())
 	case "GET":
 		// パス・クエリパラメータ取得
 		assetCode := request.PathParameters["assetCode"]
 		fromDate := request.QueryStringParameters["fromDate"]
 		toDate := request.QueryStringParameters["toDate"]
-		assetDailyData, err = models.GetAssetPriceByAssetCodeAndDate(assetCode, fromDate, toDate)
+		priceData, err = models.GetAssetPriceByAssetCodeAndDate(assetCode, fromDate, toDate)
+	}
+	// 入力内容の誤りはステータス400として返す
+	var validationError *models.ValidationError
+	if errors.As(err, &validationError) {
+		jsonBytes, _ := json.Marshal(validationError)
+		return response(string(jsonBytes), 400), nil
 	}
 	if err != nil {
 		return events.APIGatewayProxyResponse{}, err
 	}
 
-	jsonBytes, _ := json.Marshal(assetDailyData)
+	jsonBytes, _ := json.Marshal(priceData)
+	return response(string(jsonBytes), 200), nil
+}
+
+// 日本時間の当日(yyyy-mm-dd)
+func today() string {
+	jst := time.FixedZone("Asia/Tokyo", 9*60*60)
+	return time.Now().In(jst).Format("2006-01-02")
+}
+
+// httpレスポンスを生成
+func response(body string, statusCode int) events.APIGatewayProxyResponse {
 	return events.APIGatewayProxyResponse{
 		Headers: map[string]string{
 			"Access-Control-Allow-Origin":      os.Getenv("ALLOW_ORIGIN"),
@@ -74,7 +75,7 @@ func handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 			"Access-Control-Allow-Credentials": "true",
 			"Content-Type":                     "application/json",
 		},
-		Body:       string(jsonBytes),
-		StatusCode: 200,
-	}, nil
+		Body:       body,
+		StatusCode: statusCode,
+	}
 }
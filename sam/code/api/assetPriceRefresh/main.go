@@ -0,0 +1,53 @@
+package main
+
+import (
+	"code/models"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+func main() {
+	// Lambda環境以外（ローカル）では、その場で一度だけ実行する
+	// ex) go run ./api/assetPriceRefresh -to 2021-05-10
+	if os.Getenv("_LAMBDA_SERVER_PORT") == "" && os.Getenv("AWS_LAMBDA_RUNTIME_API") == "" {
+		toDate := flag.String("to", today(), "取得終了日(yyyy-mm-dd)")
+		flag.Parse()
+		if _, err := refresh(*toDate); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	lambda.Start(handler)
+}
+
+/*
+ * メインハンドラー（EventBridgeのスケジュールから実行）
+ * @param event スケジュールイベント
+ * return 実行結果
+ */
+func handler(event events.CloudWatchEvent) (models.PriceRefreshSummary, error) {
+	return refresh(today())
+}
+
+// 全資産の価格を更新し、実行結果をログに出力する
+func refresh(toDate string) (models.PriceRefreshSummary, error) {
+	summary, err := models.RefreshAllAssetPrice(toDate)
+	if err != nil {
+		return summary, err
+	}
+	jsonBytes, _ := json.Marshal(summary)
+	log.Printf("price refresh summary: %s", jsonBytes)
+	return summary, nil
+}
+
+// 日本時間の当日(yyyy-mm-dd)
+func today() string {
+	jst := time.FixedZone("Asia/Tokyo", 9*60*60)
+	return time.Now().In(jst).Format("2006-01-02")
+}
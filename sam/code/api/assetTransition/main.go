@@ -1,22 +1,15 @@
 package main
 
 import (
-	"code/config"
 	"code/models"
 	"encoding/json"
-	"math"
+	"errors"
 	"os"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 )
 
-type AssetTransition struct {
-	Date   string
-	Value  int
-	Profit int
-}
-
 func main() {
 	lambda.Start(handler)
 }
@@ -28,79 +21,51 @@ func main() {
  */
 func handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	// 変数初期化
-	var assetBuyData []models.AssetBuy
-	var err error
-
-	// 全ての資産購入データを取得し、AssetCode毎にリストを格納し、詰め直す
-	assetBuyData, err = models.GetAssetBuyByAssetCode("")
-	// AssetCode毎にリストを格納
-	assetBuyDataByAssetCode := make(map[string][]models.AssetBuy)
-	for _, data := range assetBuyData {
-		assetBuyDataByAssetCode[data.AssetCode] = append(assetBuyDataByAssetCode[data.AssetCode], data)
-	}
-
-	// 資産価値の遷移
-	var totalPastAssetValue [100]int
-	// 損益の遷移
-	var totalPastAssetProfit [100]int
-	// 日付リスト
-	var dateList [100]string
-
-	// 全資産合計の過去100日間の資産価値と損益データを算出
-	for assetCode, dataList := range assetBuyDataByAssetCode {
-		sumUnit := 0
-		sumAmount := 0
-		for _, data := range dataList {
-			sumUnit = sumUnit + data.Unit
-			sumAmount = sumAmount + data.Amount
-		}
-
-		// 資産名取得
-		assetMaster, _ := models.GetAssetMasterByAssetCodeAndCategoryId(assetCode, "")
-
-		// 投資信託であれば、基準価格=1万口に合わせて、算出する
-		basePriceConstant := 1
-		if assetMaster[0].Type == config.ASSET_TYPE_INVESTMENT_TRUST {
-			basePriceConstant = 10000
+	var tranditionData interface{}
+
+	// クエリパラメータ取得
+	// 期間(yyyy-mm-dd)、集計間隔(daily, weekly, monthly)
+	fromDate := request.QueryStringParameters["from"]
+	toDate := request.QueryStringParameters["to"]
+	interval := request.QueryStringParameters["interval"]
+	// 内訳(asset: 資産毎, category: カテゴリー毎, 指定なし: 全資産合計)
+	groupBy := request.QueryStringParameters["groupBy"]
+	// ベンチマーク(ex: ^GSPC, ^GSPC:0.6,^TPX:0.4)
+	benchmark := request.QueryStringParameters["benchmark"]
+
+	// 全ての資産購入データを取得し、日付を揃えた資産価値と損益データを算出
+	assetBuyData, err := models.GetAssetBuyByAssetCode("")
+	if err == nil {
+		var breakdown models.AssetTransitionBreakdown
+		breakdown, err = models.CalcAssetTransitionBreakdown(assetBuyData, fromDate, toDate, interval, benchmark)
+		switch groupBy {
+		case "asset":
+			tranditionData = breakdown.Asset
+		case "category":
+			tranditionData = breakdown.Category
+		case "":
+			tranditionData = breakdown.Total
+		default:
+			err = &models.ValidationError{Message: "invalid groupBy: " + groupBy}
 		}
-
-		// 資産タイプが現金とそれ以外の場合で算出方法を分ける
-		if assetMaster[0].Type != config.ASSET_TYPE_CACHE {
-			// 指定した資産の0〜100日前までの価格を取得
-			priceList, _ := models.GetAssetPriceByAssetCodeAndDate(assetCode, "", "")
-			priceListPast100 := priceList[len(priceList)-100 : len(priceList)]
-
-			for idx, data := range priceListPast100 {
-				pastAssetValue := int(math.Round(float64(data.Price) * float64(sumUnit) / float64(basePriceConstant)))
-				totalPastAssetValue[idx] = totalPastAssetValue[idx] + pastAssetValue
-				totalPastAssetProfit[idx] = totalPastAssetProfit[idx] + (pastAssetValue - sumAmount)
-				dateList[idx] = data.Date
-			}
-		} else {
-			// 現金の場合、価格一覧を参照せずに評価額を算出する
-			dayList, _ := models.GetAssetPriceByAssetCodeAndDate("9C311125", "", "")
-			dayListPast100 := dayList[len(dayList)-100 : len(dayList)]
-
-			for idx, data := range dayListPast100 {
-				totalPastAssetValue[idx] = totalPastAssetValue[idx] + sumUnit
-				dateList[idx] = data.Date
-			}
-		}
-
 	}
 
-	// 構造体のスライス形式になるように形式を変換
-	var tranditionDataList []AssetTransition
-	for idx, date := range dateList {
-		tranditionData := AssetTransition{Date: date, Value: totalPastAssetValue[idx], Profit: totalPastAssetProfit[idx]}
-		tranditionDataList = append(tranditionDataList, tranditionData)
+	// 入力内容の誤りはステータス400として返す
+	var validationError *models.ValidationError
+	if errors.As(err, &validationError) {
+		jsonBytes, _ := json.Marshal(validationError)
+		return response(string(jsonBytes), 400), nil
 	}
-
 	if err != nil {
 		return events.APIGatewayProxyResponse{}, err
 	}
 
-	jsonBytes, _ := json.Marshal(tranditionDataList)
+	jsonBytes, _ := json.Marshal(tranditionData)
+	return response(string(jsonBytes), 200), nil
+}
+
+// httpレスポンスを生成
+func response(body string, statusCode int) events.APIGatewayProxyResponse {
 	return events.APIGatewayProxyResponse{
 		Headers: map[string]string{
 			"Access-Control-Allow-Origin":      os.Getenv("ALLOW_ORIGIN"),
@@ -108,7 +73,7 @@ func handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 			"Access-Control-Allow-Credentials": "true",
 			"Content-Type":                     "application/json",
 		},
-		Body:       string(jsonBytes),
-		StatusCode: 200,
-	}, nil
+		Body:       body,
+		StatusCode: statusCode,
+	}
 }
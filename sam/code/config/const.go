@@ -11,3 +11,66 @@ const ASSET_TYPE_INVESTMENT_TRUST = 3
 
 // 資産タイプ：現金
 const ASSET_TYPE_CACHE = 4
+
+// 資産タイプ：指数（ベンチマーク用。価格のみ取得し、保有はできない）
+const ASSET_TYPE_INDEX = 5
+
+// 取引種別：購入
+const TRADE_TYPE_BUY = 1
+
+// 取引種別：売却
+const TRADE_TYPE_SELL = 2
+
+// 価格取得元：SBI証券（投資信託の基準価格）
+const PRICE_PROVIDER_SBI = "sbi"
+
+// 価格取得元：Yahoo Finance API（株価）
+const PRICE_PROVIDER_YAHOO_FINANCE = "yahooFinance"
+
+// 通貨：円（基準通貨の既定値）
+const CURRENCY_JPY = "JPY"
+
+// 推移の集計間隔：日次
+const TRANSITION_INTERVAL_DAILY = "daily"
+
+// 推移の集計間隔：週次
+const TRANSITION_INTERVAL_WEEKLY = "weekly"
+
+// 推移の集計間隔：月次
+const TRANSITION_INTERVAL_MONTHLY = "monthly"
+
+// 資産カテゴリー（カテゴリーID：カテゴリー名）
+var ASSET_CATEGORY_LIST = map[int]string{1: "国内株", 2: "先進国株", 3: "新興株", 4: "先進国債券", 5: "新興国債券", 6: "コモディティ", 7: "暗号資産", 8: "現金"}
+
+// 指数を資産マスタに登録する際のカテゴリーID
+const INDEX_CATEGORY_ID = "0"
+
+// 目標配分の種別：カテゴリー毎
+const ALLOCATION_TARGET_TYPE_CATEGORY = "category"
+
+// 目標配分の種別：資産毎
+const ALLOCATION_TARGET_TYPE_ASSET = "asset"
+
+// リバランス方法：売買により目標配分に合わせる
+const REBALANCE_MODE_FULL = "full"
+
+// リバランス方法：追加資金のみで配分不足を購入する（売却しない）
+const REBALANCE_MODE_NEW_MONEY = "newMoney"
+
+// 積立の頻度：毎営業日
+const PLAN_SCHEDULE_DAILY = "daily"
+
+// 積立の頻度：毎月（指定日。休場日の場合は翌営業日）
+const PLAN_SCHEDULE_MONTHLY = "monthly"
+
+// 取引日に価格がない場合の価格の決め方：直前の価格
+const PRICE_POLICY_PRIOR = "prior"
+
+// 取引日に価格がない場合の価格の決め方：直後の価格
+const PRICE_POLICY_NEXT = "next"
+
+// 資産マスタ一覧の1ページあたりの件数（指定がない場合）
+const ASSET_MASTER_PAGE_LIMIT_DEFAULT = 50
+
+// 資産マスタ一覧の1ページあたりの最大件数
+const ASSET_MASTER_PAGE_LIMIT_MAX = 200
@@ -0,0 +1,318 @@
+package decimal
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// 内部で保持する小数点以下の桁数
+const scale = 6
+
+// 10^scale
+const scaleFactor int64 = 1000000
+
+// 保持できる値の絶対値の上限（丸めで桁が上がっても int64 に収まるよう、1だけ余裕を持たせる）
+const maxValue int64 = math.MaxInt64 - scaleFactor
+
+// 0で除算した
+var ErrDivisionByZero = errors.New("decimal: division by zero")
+
+// 計算結果が保持できる範囲を超えた
+var ErrOverflow = errors.New("decimal: out of range")
+
+/*
+ * 金額・価格・口数を扱う固定小数点数（小数点以下6桁）
+ * 浮動小数点数の誤差を避けるため、10^6倍した整数で保持する
+ * 保持できる範囲は整数部で約±9.2兆まで（乗除算で範囲を超える場合はエラーを返す）
+ */
+type Decimal struct {
+	value int64
+}
+
+// 0
+var Zero = Decimal{}
+
+/*
+ * 整数から生成
+ */
+func NewFromInt(i int64) Decimal {
+	return Decimal{value: i * scaleFactor}
+}
+
+/*
+ * 浮動小数点数から生成（小数点以下7桁目を四捨五入）
+ */
+func NewFromFloat(f float64) Decimal {
+	return Decimal{value: int64(math.Round(f * float64(scaleFactor)))}
+}
+
+/*
+ * 文字列("1234", "-12.345")から生成
+ */
+func NewFromString(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Zero, errors.New("decimal: empty string")
+	}
+	negative := false
+	if s[0] == '-' || s[0] == '+' {
+		negative = s[0] == '-'
+		s = s[1:]
+	}
+	intPart := s
+	fracPart := ""
+	if idx := strings.IndexByte(s, '.'); idx >= 0 {
+		intPart = s[:idx]
+		fracPart = s[idx+1:]
+	}
+	// 符号のみ・小数点のみや、数字以外を含む文字列は受け付けない
+	if (intPart == "" && fracPart == "") || !isDigits(intPart) || !isDigits(fracPart) {
+		return Zero, errors.New("decimal: invalid string " + s)
+	}
+	if intPart == "" {
+		intPart = "0"
+	}
+	// 7桁目以降は四捨五入する
+	roundUp := false
+	if len(fracPart) > scale {
+		roundUp = fracPart[scale] >= '5'
+		fracPart = fracPart[:scale]
+	}
+	fracPart = fracPart + strings.Repeat("0", scale-len(fracPart))
+
+	i, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil || i > maxValue/scaleFactor {
+		return Zero, errors.New("decimal: out of range " + s)
+	}
+	f, _ := strconv.ParseInt(fracPart, 10, 64)
+	value := i*scaleFactor + f
+	if roundUp {
+		value++
+	}
+	if value > maxValue {
+		return Zero, errors.New("decimal: out of range " + s)
+	}
+	if negative {
+		value = -value
+	}
+	return Decimal{value: value}, nil
+}
+
+// 加算
+func (d Decimal) Add(d2 Decimal) Decimal {
+	return Decimal{value: d.value + d2.value}
+}
+
+// 減算
+func (d Decimal) Sub(d2 Decimal) Decimal {
+	return Decimal{value: d.value - d2.value}
+}
+
+// 乗算（小数点以下7桁目を四捨五入。保持できる範囲を超える場合は ErrOverflow）
+func (d Decimal) Mul(d2 Decimal) (Decimal, error) {
+	product := new(big.Int).Mul(big.NewInt(d.value), big.NewInt(d2.value))
+	value, err := divRound(product, big.NewInt(scaleFactor))
+	return Decimal{value: value}, err
+}
+
+// 除算（小数点以下7桁目を四捨五入。0除算の場合は ErrDivisionByZero、保持できる範囲を超える場合は ErrOverflow）
+func (d Decimal) Div(d2 Decimal) (Decimal, error) {
+	if d2.value == 0 {
+		return Zero, ErrDivisionByZero
+	}
+	numerator := new(big.Int).Mul(big.NewInt(d.value), big.NewInt(scaleFactor))
+	value, err := divRound(numerator, big.NewInt(d2.value))
+	return Decimal{value: value}, err
+}
+
+// 符号反転
+func (d Decimal) Neg() Decimal {
+	return Decimal{value: -d.value}
+}
+
+// 絶対値
+func (d Decimal) Abs() Decimal {
+	if d.value < 0 {
+		return d.Neg()
+	}
+	return d
+}
+
+/*
+ * 指定した小数点以下の桁数(0以上)に四捨五入（0から遠い方に丸める）
+ */
+func (d Decimal) Round(places int) Decimal {
+	if places >= scale {
+		return d
+	}
+	unit := pow10(scale - places)
+	// 値の絶対値は maxValue 以下のため、丸めて桁が上がっても int64 に収まる
+	quotient, _ := divRound(big.NewInt(d.value), big.NewInt(unit))
+	return Decimal{value: quotient * unit}
+}
+
+/*
+ * 指定した小数点以下の桁数に切り捨て（0に近い方に丸める）
+ */
+func (d Decimal) Truncate(places int) Decimal {
+	if places >= scale {
+		return d
+	}
+	unit := pow10(scale - places)
+	return Decimal{value: d.value / unit * unit}
+}
+
+// 符号（-1, 0, 1）
+func (d Decimal) Sign() int {
+	switch {
+	case d.value < 0:
+		return -1
+	case d.value > 0:
+		return 1
+	}
+	return 0
+}
+
+// 0であるか
+func (d Decimal) IsZero() bool {
+	return d.value == 0
+}
+
+// 比較（d < d2: -1, d == d2: 0, d > d2: 1）
+func (d Decimal) Cmp(d2 Decimal) int {
+	return d.Sub(d2).Sign()
+}
+
+// 等しいか
+func (d Decimal) Equal(d2 Decimal) bool {
+	return d.value == d2.value
+}
+
+// d < d2 であるか
+func (d Decimal) LessThan(d2 Decimal) bool {
+	return d.value < d2.value
+}
+
+// d > d2 であるか
+func (d Decimal) GreaterThan(d2 Decimal) bool {
+	return d.value > d2.value
+}
+
+// 浮動小数点数に変換（統計処理など誤差を許容する計算用）
+func (d Decimal) Float64() float64 {
+	return float64(d.value) / float64(scaleFactor)
+}
+
+// 整数部
+func (d Decimal) IntPart() int64 {
+	return d.value / scaleFactor
+}
+
+// 文字列に変換（末尾の0は出力しない）
+func (d Decimal) String() string {
+	abs := d.value
+	sign := ""
+	if abs < 0 {
+		abs = -abs
+		sign = "-"
+	}
+	intPart := strconv.FormatInt(abs/scaleFactor, 10)
+	fracPart := strings.TrimRight(strconv.FormatInt(abs%scaleFactor+scaleFactor, 10)[1:], "0")
+	if fracPart == "" {
+		return sign + intPart
+	}
+	return sign + intPart + "." + fracPart
+}
+
+// JSONの数値として出力
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// JSONの数値または文字列から読み込み
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), "\"")
+	if s == "null" || s == "" {
+		*d = Zero
+		return nil
+	}
+	parsed, err := NewFromString(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// DynamoDBの数値型(N)として保存
+func (d Decimal) MarshalDynamo() (*dynamodb.AttributeValue, error) {
+	return &dynamodb.AttributeValue{N: aws.String(d.String())}, nil
+}
+
+// DynamoDBの数値型(N)から読み込み
+func (d *Decimal) UnmarshalDynamo(av *dynamodb.AttributeValue) error {
+	if av.N == nil {
+		return errors.New("decimal: attribute is not a number")
+	}
+	parsed, err := NewFromString(*av.N)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+/*
+ * 合計
+ */
+func Sum(list ...Decimal) Decimal {
+	total := Zero
+	for _, d := range list {
+		total = total.Add(d)
+	}
+	return total
+}
+
+// 四捨五入の除算（0から遠い方に丸める。結果が保持できる範囲を超える場合は ErrOverflow）
+func divRound(numerator *big.Int, denominator *big.Int) (int64, error) {
+	quotient, remainder := new(big.Int).QuoRem(numerator, denominator, new(big.Int))
+	// 余りの2倍が除数以上であれば絶対値を切り上げる
+	doubled := new(big.Int).Abs(remainder)
+	doubled.Mul(doubled, big.NewInt(2))
+	if doubled.Cmp(new(big.Int).Abs(denominator)) >= 0 {
+		if (numerator.Sign() < 0) != (denominator.Sign() < 0) {
+			quotient.Sub(quotient, big.NewInt(1))
+		} else {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+	if !quotient.IsInt64() || quotient.Int64() > maxValue || quotient.Int64() < -maxValue {
+		return 0, ErrOverflow
+	}
+	return quotient.Int64(), nil
+}
+
+// 数字のみからなるか（空文字は true）
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// 10のn乗
+func pow10(n int) int64 {
+	result := int64(1)
+	for i := 0; i < n; i++ {
+		result = result * 10
+	}
+	return result
+}
@@ -0,0 +1,221 @@
+package decimal
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// テスト用に文字列から生成（失敗した場合はテストを中断する）
+func mustParse(t *testing.T, s string) Decimal {
+	t.Helper()
+	d, err := NewFromString(s)
+	if err != nil {
+		t.Fatalf("NewFromString(%q): %v", s, err)
+	}
+	return d
+}
+
+func TestNewFromString(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{"0", "0"},
+		{"1234", "1234"},
+		{"-12.345", "-12.345"},
+		{"+1.5", "1.5"},
+		{" 7 ", "7"},
+		{".5", "0.5"},
+		{"5.", "5"},
+		{"0.0000004", "0"},
+		{"0.0000005", "0.000001"},
+		{"-0.0000005", "-0.000001"},
+		{"1.2345674", "1.234567"},
+		{"9223372036853.775807", "9223372036853.775807"},
+	}
+	for _, c := range cases {
+		if got := mustParse(t, c.in).String(); got != c.want {
+			t.Errorf("NewFromString(%q) = %s, want %s", c.in, got, c.want)
+		}
+	}
+}
+
+func TestNewFromStringInvalid(t *testing.T) {
+	for _, in := range []string{"", " ", "-", "+", ".", "-.", "abc", "1.2.3", "1e5", "+-1", "--1", "1.+5", "1.-5", "1,000",
+		"9223372036853.775808", "9223372036854", "99999999999999999999"} {
+		if d, err := NewFromString(in); err == nil {
+			t.Errorf("NewFromString(%q) = %s, want error", in, d)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	cases := []struct {
+		in   Decimal
+		want string
+	}{
+		{Zero, "0"},
+		{NewFromInt(-3), "-3"},
+		{Decimal{value: 1}, "0.000001"},
+		{Decimal{value: -1}, "-0.000001"},
+		{Decimal{value: 1500000}, "1.5"},
+		{NewFromFloat(0.1), "0.1"},
+	}
+	for _, c := range cases {
+		if got := c.in.String(); got != c.want {
+			t.Errorf("String() = %s, want %s", got, c.want)
+		}
+	}
+}
+
+func TestRoundAndTruncate(t *testing.T) {
+	cases := []struct {
+		in        string
+		places    int
+		round     string
+		truncated string
+	}{
+		{"1.25", 1, "1.3", "1.2"},
+		{"-1.25", 1, "-1.3", "-1.2"},
+		{"1.24", 1, "1.2", "1.2"},
+		{"2.5", 0, "3", "2"},
+		{"-2.5", 0, "-3", "-2"},
+		{"123.456789", 4, "123.4568", "123.4567"},
+		{"123.456789", 6, "123.456789", "123.456789"},
+		{"9223372036853.775807", 0, "9223372036854", "9223372036853"},
+	}
+	for _, c := range cases {
+		d := mustParse(t, c.in)
+		if got := d.Round(c.places).String(); got != c.round {
+			t.Errorf("%s.Round(%d) = %s, want %s", c.in, c.places, got, c.round)
+		}
+		if got := d.Truncate(c.places).String(); got != c.truncated {
+			t.Errorf("%s.Truncate(%d) = %s, want %s", c.in, c.places, got, c.truncated)
+		}
+	}
+}
+
+func TestMulDiv(t *testing.T) {
+	cases := []struct {
+		a, b     string
+		mul, div string
+	}{
+		{"2", "3", "6", "0.666667"},
+		{"-2", "3", "-6", "-0.666667"},
+		{"1.5", "-0.5", "-0.75", "-3"},
+		{"0.001", "0.001", "0.000001", "1"},
+		{"0.000001", "0.4", "0", "0.000003"},
+		{"12345.6789", "10000", "123456789", "1.234568"},
+	}
+	for _, c := range cases {
+		a, b := mustParse(t, c.a), mustParse(t, c.b)
+		if got, err := a.Mul(b); err != nil || got.String() != c.mul {
+			t.Errorf("%s.Mul(%s) = %s, %v, want %s", c.a, c.b, got, err, c.mul)
+		}
+		if got, err := a.Div(b); err != nil || got.String() != c.div {
+			t.Errorf("%s.Div(%s) = %s, %v, want %s", c.a, c.b, got, err, c.div)
+		}
+	}
+}
+
+func TestDivisionByZero(t *testing.T) {
+	if _, err := NewFromInt(1).Div(Zero); !errors.Is(err, ErrDivisionByZero) {
+		t.Errorf("Div(0) error = %v, want ErrDivisionByZero", err)
+	}
+}
+
+func TestOverflow(t *testing.T) {
+	large := mustParse(t, "9000000000000")
+	if _, err := large.Mul(NewFromInt(2)); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Mul overflow error = %v, want ErrOverflow", err)
+	}
+	if _, err := large.Neg().Mul(NewFromInt(2)); !errors.Is(err, ErrOverflow) {
+		t.Errorf("negative Mul overflow error = %v, want ErrOverflow", err)
+	}
+	if _, err := large.Div(mustParse(t, "0.5")); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Div overflow error = %v, want ErrOverflow", err)
+	}
+	// int64 を大きく超える積も桁あふれせずにエラーとする
+	if _, err := large.Mul(large); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Mul int64 overflow error = %v, want ErrOverflow", err)
+	}
+	if got, err := large.Mul(NewFromInt(1)); err != nil || !got.Equal(large) {
+		t.Errorf("Mul(1) = %s, %v, want %s", got, err, large)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	a, b := mustParse(t, "1.5"), mustParse(t, "-2")
+	if a.Cmp(b) != 1 || b.Cmp(a) != -1 || a.Cmp(a) != 0 {
+		t.Errorf("Cmp is wrong")
+	}
+	if !b.LessThan(a) || !a.GreaterThan(b) || a.Equal(b) {
+		t.Errorf("LessThan/GreaterThan/Equal is wrong")
+	}
+	if b.Abs().String() != "2" || b.Sign() != -1 || !Zero.IsZero() {
+		t.Errorf("Abs/Sign/IsZero is wrong")
+	}
+	if got := Sum(a, b, NewFromInt(1)).String(); got != "0.5" {
+		t.Errorf("Sum = %s, want 0.5", got)
+	}
+	if got := mustParse(t, "-12.9").IntPart(); got != -12 {
+		t.Errorf("IntPart = %d, want -12", got)
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	type item struct {
+		Amount Decimal
+	}
+	for _, s := range []string{"0", "1234.5", "-0.000001", "9223372036853.775807"} {
+		jsonBytes, err := json.Marshal(item{Amount: mustParse(t, s)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := `{"Amount":` + s + `}`; string(jsonBytes) != want {
+			t.Errorf("json.Marshal = %s, want %s", jsonBytes, want)
+		}
+		var decoded item
+		if err := json.Unmarshal(jsonBytes, &decoded); err != nil || decoded.Amount.String() != s {
+			t.Errorf("json.Unmarshal(%s) = %s, %v", jsonBytes, decoded.Amount, err)
+		}
+	}
+
+	// 文字列・null も読み込める
+	var decoded item
+	if err := json.Unmarshal([]byte(`{"Amount":"12.5"}`), &decoded); err != nil || decoded.Amount.String() != "12.5" {
+		t.Errorf("json.Unmarshal(string) = %s, %v", decoded.Amount, err)
+	}
+	if err := json.Unmarshal([]byte(`{"Amount":null}`), &decoded); err != nil || !decoded.Amount.IsZero() {
+		t.Errorf("json.Unmarshal(null) = %s, %v", decoded.Amount, err)
+	}
+	if err := json.Unmarshal([]byte(`{"Amount":"-"}`), &decoded); err == nil {
+		t.Errorf("json.Unmarshal(\"-\") want error")
+	}
+}
+
+func TestDynamoRoundTrip(t *testing.T) {
+	for _, s := range []string{"0", "1234.5", "-0.000001"} {
+		av, err := mustParse(t, s).MarshalDynamo()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if av.N == nil || *av.N != s {
+			t.Errorf("MarshalDynamo(%s) = %v", s, av)
+		}
+		var decoded Decimal
+		if err := decoded.UnmarshalDynamo(av); err != nil || decoded.String() != s {
+			t.Errorf("UnmarshalDynamo(%s) = %s, %v", s, decoded, err)
+		}
+	}
+
+	// 数値型以外は読み込めない
+	var decoded Decimal
+	text := "1"
+	av, _ := mustParse(t, "1").MarshalDynamo()
+	av.S, av.N = &text, nil
+	if err := decoded.UnmarshalDynamo(av); err == nil {
+		t.Errorf("UnmarshalDynamo(S) want error")
+	}
+}
@@ -0,0 +1,301 @@
+package models
+
+import (
+	"code/config"
+	"code/decimal"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// 目標配分比率の合計の許容誤差
+const allocationWeightTolerance = 1e-6
+
+// 目標配分（比率は全資産合計に対する割合 0〜1）
+type AllocationTarget struct {
+	// 種別（category, asset）
+	TargetType string
+	// カテゴリーIDまたは資産コード
+	Code   string
+	Weight decimal.Decimal
+}
+type AllocationTargetReq struct {
+	TargetType string          `json:"TargetType"`
+	Code       string          `json:"Code"`
+	Weight     decimal.Decimal `json:"Weight"`
+}
+
+// 現在の配分と目標配分との乖離
+type AllocationDrift struct {
+	Code         string
+	Name         string
+	PresentValue decimal.Decimal
+	// 現在の比率
+	CurrentWeight float64
+	// 目標比率（目標がない場合は nil）
+	TargetWeight *float64
+	// 現在の比率 - 目標比率
+	Drift *float64
+}
+
+// リバランスの売買金額（正は購入、負は売却）
+type RebalanceTrade struct {
+	Code   string
+	Name   string
+	Amount decimal.Decimal
+	// リバランス後の比率
+	WeightAfter float64
+}
+
+// リバランス案
+type RebalancePlan struct {
+	// リバランス方法（full, newMoney）
+	Mode string
+	// 評価日
+	Date         string
+	TotalValue   decimal.Decimal
+	Contribution decimal.Decimal
+	// カテゴリー毎・資産毎の乖離
+	CategoryDrift []AllocationDrift
+	AssetDrift    []AllocationDrift
+	// カテゴリー毎・資産毎の売買金額（目標が設定されているもののみ）
+	CategoryTrade []RebalanceTrade
+	AssetTrade    []RebalanceTrade
+}
+
+// 配分を算出する資産のまとまり
+type allocationGroup struct {
+	code   string
+	name   string
+	value  decimal.Decimal
+	target *float64
+}
+
+/*
+ * 目標配分の一覧を取得
+ */
+func GetAllocationTargetList() ([]AllocationTarget, error) {
+	return repository.AllocationTarget.FindAll()
+}
+
+/*
+ * 目標配分を保存（比率0の場合は目標を削除する）
+ * 同じ種別の目標比率の合計が1を超える場合はエラー
+ */
+func SaveAllocationTarget(allocationTargetReq *AllocationTargetReq) error {
+	targetType := allocationTargetReq.TargetType
+	code := allocationTargetReq.Code
+	weight := allocationTargetReq.Weight
+	switch targetType {
+	case config.ALLOCATION_TARGET_TYPE_CATEGORY:
+		id, err := strconv.Atoi(code)
+		if _, ok := config.ASSET_CATEGORY_LIST[id]; err != nil || !ok {
+			return newValidationError("invalid category id: " + code)
+		}
+	case config.ALLOCATION_TARGET_TYPE_ASSET:
+		if _, err := findAssetMaster(code); err != nil {
+			return err
+		}
+	default:
+		return newValidationError("invalid target type: " + targetType)
+	}
+	if weight.Sign() < 0 || weight.GreaterThan(decimal.NewFromInt(1)) {
+		return newValidationError("weight must be between 0 and 1")
+	}
+
+	// 同じ種別の他の目標と合わせた比率の合計を確認する
+	allocationTargetList, err := GetAllocationTargetList()
+	if err != nil {
+		return err
+	}
+	totalWeight := weight
+	for _, target := range allocationTargetList {
+		if target.TargetType == targetType && target.Code != code {
+			totalWeight = totalWeight.Add(target.Weight)
+		}
+	}
+	if totalWeight.GreaterThan(decimal.NewFromInt(1)) {
+		return newValidationError("total weight exceeds 1: " + totalWeight.String())
+	}
+
+	if weight.IsZero() {
+		return repository.AllocationTarget.Delete(targetType, code)
+	}
+	return repository.AllocationTarget.Save(AllocationTarget{TargetType: targetType, Code: code, Weight: weight})
+}
+
+/*
+ * 保有資産の最新の評価額と目標配分から、乖離とリバランス案を算出
+ * mode が full の場合は追加資金を含めて目標配分に合わせる売買金額を、
+ * newMoney の場合は追加資金のみを配分不足のカテゴリー・資産に割り当てる購入金額を算出する
+ * カテゴリーの目標比率は合計1で設定されている必要がある（資産の目標は設定されたもののみ対象）
+ */
+func CalcRebalancePlan(assetBuyList []AssetBuy, allocationTargetList []AllocationTarget, contribution decimal.Decimal, mode string) (RebalancePlan, error) {
+	if mode == "" {
+		mode = config.REBALANCE_MODE_FULL
+	}
+	plan := RebalancePlan{Mode: mode, Contribution: contribution}
+	if mode != config.REBALANCE_MODE_FULL && mode != config.REBALANCE_MODE_NEW_MONEY {
+		return plan, newValidationError("invalid rebalance mode: " + mode)
+	}
+	if contribution.Sign() < 0 {
+		return plan, newValidationError("contribution must not be negative")
+	}
+	if mode == config.REBALANCE_MODE_NEW_MONEY && contribution.IsZero() {
+		return plan, newValidationError("contribution is required for new money mode")
+	}
+
+	// 目標比率を種別毎に格納
+	targetByType := map[string]map[string]float64{
+		config.ALLOCATION_TARGET_TYPE_CATEGORY: {},
+		config.ALLOCATION_TARGET_TYPE_ASSET:    {},
+	}
+	for _, target := range allocationTargetList {
+		if targetList, ok := targetByType[target.TargetType]; ok {
+			targetList[target.Code] = target.Weight.Float64()
+		}
+	}
+	categoryTotalWeight := 0.0
+	for _, weight := range targetByType[config.ALLOCATION_TARGET_TYPE_CATEGORY] {
+		categoryTotalWeight = categoryTotalWeight + weight
+	}
+	if math.Abs(categoryTotalWeight-1) > allocationWeightTolerance {
+		return plan, newValidationError("category target weights must sum to 1")
+	}
+
+	// 保有資産の最新の評価額（基準通貨）
+	valuationList, err := loadAssetValuationList(assetBuyList)
+	if err != nil {
+		return plan, err
+	}
+	dateList := transitionDateList(valuationList)
+	if len(dateList) == 0 {
+		return plan, newValidationError("no asset holding")
+	}
+	plan.Date = dateList[len(dateList)-1]
+
+	// カテゴリー毎・資産毎にまとめる（目標が設定されていて保有のないカテゴリー・資産も含める）
+	var categoryGroupList []allocationGroup
+	categoryIndex := make(map[string]int)
+	var categoryIdList []int
+	for categoryId := range config.ASSET_CATEGORY_LIST {
+		categoryIdList = append(categoryIdList, categoryId)
+	}
+	sort.Ints(categoryIdList)
+	for _, categoryId := range categoryIdList {
+		code := strconv.Itoa(categoryId)
+		categoryIndex[code] = len(categoryGroupList)
+		categoryGroupList = append(categoryGroupList, allocationGroup{code: code, name: config.ASSET_CATEGORY_LIST[categoryId]})
+	}
+	var assetGroupList []allocationGroup
+	assetIndex := make(map[string]int)
+	for _, valuation := range valuationList {
+		value, _, err := valuation.valueAt(plan.Date)
+		if err != nil {
+			return plan, err
+		}
+		plan.TotalValue = plan.TotalValue.Add(value)
+
+		assetIndex[valuation.assetMaster.AssetCode] = len(assetGroupList)
+		assetGroupList = append(assetGroupList, allocationGroup{code: valuation.assetMaster.AssetCode,
+			name: valuation.assetMaster.Name, value: value})
+
+		categoryId := valuation.assetMaster.CategoryId
+		if _, ok := categoryIndex[categoryId]; !ok {
+			categoryIndex[categoryId] = len(categoryGroupList)
+			categoryGroupList = append(categoryGroupList, allocationGroup{code: categoryId})
+		}
+		category := &categoryGroupList[categoryIndex[categoryId]]
+		category.value = category.value.Add(value)
+	}
+	var targetAssetCodeList []string
+	for code := range targetByType[config.ALLOCATION_TARGET_TYPE_ASSET] {
+		if _, ok := assetIndex[code]; !ok {
+			targetAssetCodeList = append(targetAssetCodeList, code)
+		}
+	}
+	sort.Strings(targetAssetCodeList)
+	for _, code := range targetAssetCodeList {
+		name, _ := GetAssetName(code)
+		assetIndex[code] = len(assetGroupList)
+		assetGroupList = append(assetGroupList, allocationGroup{code: code, name: name})
+	}
+
+	// 目標比率を設定する（カテゴリーは目標がなければ0とする）
+	for idx := range categoryGroupList {
+		weight := targetByType[config.ALLOCATION_TARGET_TYPE_CATEGORY][categoryGroupList[idx].code]
+		categoryGroupList[idx].target = &weight
+	}
+	for code, weight := range targetByType[config.ALLOCATION_TARGET_TYPE_ASSET] {
+		weight := weight
+		assetGroupList[assetIndex[code]].target = &weight
+	}
+
+	plan.CategoryDrift = calcAllocationDrift(categoryGroupList, plan.TotalValue)
+	plan.AssetDrift = calcAllocationDrift(assetGroupList, plan.TotalValue)
+	plan.CategoryTrade = calcRebalanceTrade(categoryGroupList, plan.TotalValue, contribution, mode)
+	plan.AssetTrade = calcRebalanceTrade(assetGroupList, plan.TotalValue, contribution, mode)
+	return plan, nil
+}
+
+// 現在の比率と目標比率との乖離を算出
+func calcAllocationDrift(groupList []allocationGroup, totalValue decimal.Decimal) []AllocationDrift {
+	var driftList []AllocationDrift
+	for _, group := range groupList {
+		drift := AllocationDrift{Code: group.code, Name: group.name, PresentValue: group.value,
+			TargetWeight: group.target}
+		if !totalValue.IsZero() {
+			drift.CurrentWeight = group.value.Float64() / totalValue.Float64()
+		}
+		if group.target != nil {
+			diff := drift.CurrentWeight - *group.target
+			drift.Drift = &diff
+		}
+		driftList = append(driftList, drift)
+	}
+	return driftList
+}
+
+// 目標が設定されているまとまり毎の売買金額を算出
+// full: 追加資金を含めた合計に目標比率を掛けた金額との差額を売買する
+// newMoney: 追加資金を含めた合計での目標金額に対する不足額の割合で、追加資金を配分する（売却しない）
+func calcRebalanceTrade(groupList []allocationGroup, totalValue decimal.Decimal, contribution decimal.Decimal, mode string) []RebalanceTrade {
+	var tradeList []RebalanceTrade
+	totalAfter := totalValue.Add(contribution)
+	if totalAfter.IsZero() {
+		return tradeList
+	}
+
+	// 目標金額との差額（正は不足）
+	var shortageList []float64
+	totalShortage := 0.0
+	for _, group := range groupList {
+		shortage := 0.0
+		if group.target != nil {
+			shortage = *group.target*totalAfter.Float64() - group.value.Float64()
+		}
+		shortageList = append(shortageList, shortage)
+		if shortage > 0 {
+			totalShortage = totalShortage + shortage
+		}
+	}
+
+	for idx, group := range groupList {
+		if group.target == nil {
+			continue
+		}
+		amount := shortageList[idx]
+		if mode == config.REBALANCE_MODE_NEW_MONEY {
+			amount = 0
+			if shortageList[idx] > 0 {
+				// 不足額の合計が追加資金より少ない場合は不足額まで購入する
+				amount = math.Min(shortageList[idx], contribution.Float64()*shortageList[idx]/totalShortage)
+			}
+		}
+		trade := RebalanceTrade{Code: group.code, Name: group.name,
+			Amount: RoundAmount(decimal.NewFromFloat(amount), BaseCurrency())}
+		trade.WeightAfter = group.value.Add(trade.Amount).Float64() / totalAfter.Float64()
+		tradeList = append(tradeList, trade)
+	}
+	return tradeList
+}
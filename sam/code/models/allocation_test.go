@@ -0,0 +1,78 @@
+package models
+
+import (
+	"code/config"
+	"code/decimal"
+	"errors"
+	"testing"
+)
+
+func TestSaveAllocationTarget(t *testing.T) {
+	defer SetRepository(SetRepository(NewMemoryRepository()))
+	saveTestAssetMasterAndPrice(t)
+
+	for _, req := range []AllocationTargetReq{
+		{TargetType: config.ALLOCATION_TARGET_TYPE_CATEGORY, Code: "1", Weight: mustDecimal(t, "0.6")},
+		{TargetType: config.ALLOCATION_TARGET_TYPE_CATEGORY, Code: "2", Weight: mustDecimal(t, "0.4")},
+		{TargetType: config.ALLOCATION_TARGET_TYPE_ASSET, Code: "7203.T", Weight: mustDecimal(t, "0.3")},
+	} {
+		if err := SaveAllocationTarget(&req); err != nil {
+			t.Fatalf("SaveAllocationTarget(%+v): %v", req, err)
+		}
+	}
+
+	// 同じ種別の合計が1を超える目標は保存しない
+	var validationError *ValidationError
+	if err := SaveAllocationTarget(&AllocationTargetReq{TargetType: config.ALLOCATION_TARGET_TYPE_CATEGORY, Code: "3", Weight: mustDecimal(t, "0.1")}); !errors.As(err, &validationError) {
+		t.Errorf("total weight over 1: error = %v, want ValidationError", err)
+	}
+	// 比率0は目標の削除
+	if err := SaveAllocationTarget(&AllocationTargetReq{TargetType: config.ALLOCATION_TARGET_TYPE_ASSET, Code: "7203.T"}); err != nil {
+		t.Fatal(err)
+	}
+
+	targetList, err := GetAllocationTargetList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targetList) != 2 || targetList[0].Code != "1" || targetList[0].Weight.String() != "0.6" ||
+		targetList[1].Code != "2" || targetList[1].Weight.String() != "0.4" {
+		t.Errorf("GetAllocationTargetList = %+v, want category 1: 0.6, 2: 0.4", targetList)
+	}
+}
+
+// 目標・リバランス条件の誤りは入力内容の誤りとする
+func TestAllocationValidationError(t *testing.T) {
+	defer SetRepository(SetRepository(NewMemoryRepository()))
+	saveTestAssetMasterAndPrice(t)
+
+	var validationError *ValidationError
+	for _, req := range []AllocationTargetReq{
+		{TargetType: config.ALLOCATION_TARGET_TYPE_CATEGORY, Code: "99", Weight: mustDecimal(t, "0.1")},
+		{TargetType: config.ALLOCATION_TARGET_TYPE_ASSET, Code: "UNKNOWN", Weight: mustDecimal(t, "0.1")},
+		{TargetType: "region", Code: "1", Weight: mustDecimal(t, "0.1")},
+		{TargetType: config.ALLOCATION_TARGET_TYPE_CATEGORY, Code: "1", Weight: mustDecimal(t, "1.1")},
+	} {
+		if err := SaveAllocationTarget(&req); !errors.As(err, &validationError) {
+			t.Errorf("SaveAllocationTarget(%+v) error = %v, want ValidationError", req, err)
+		}
+	}
+
+	categoryTarget := []AllocationTarget{{TargetType: config.ALLOCATION_TARGET_TYPE_CATEGORY, Code: "1", Weight: decimal.NewFromInt(1)}}
+	cases := []struct {
+		targetList   []AllocationTarget
+		contribution decimal.Decimal
+		mode         string
+	}{
+		{categoryTarget, decimal.Zero, "sell"},
+		{categoryTarget, decimal.NewFromInt(-1), config.REBALANCE_MODE_FULL},
+		{categoryTarget, decimal.Zero, config.REBALANCE_MODE_NEW_MONEY},
+		{nil, decimal.Zero, config.REBALANCE_MODE_FULL},
+		{categoryTarget, decimal.Zero, config.REBALANCE_MODE_FULL},
+	}
+	for _, c := range cases {
+		if _, err := CalcRebalancePlan(nil, c.targetList, c.contribution, c.mode); !errors.As(err, &validationError) {
+			t.Errorf("CalcRebalancePlan(%+v) error = %v, want ValidationError", c, err)
+		}
+	}
+}
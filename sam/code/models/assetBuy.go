@@ -2,72 +2,437 @@ package models
 
 import (
 	"code/config"
-	"math"
+	"code/decimal"
+	"time"
 )
 
 type AssetBuy struct {
-	AssetCode string
-	Date      string
-	Unit      int
-	Amount    int
+	AssetCode     string
+	TransactionId string
+	// 約定日
+	Date string
+	// 注文日（約定日オフセットのある資産は約定日と異なる）
+	OrderDate string
+	TradeType int
+	Unit      decimal.Decimal
+	Amount    decimal.Decimal
+	// 積立プランから登録した取引のプランID
+	PlanId string
+	// 約定に使用した価格の日付（約定日に価格がない場合は直前または直後の日付。現金は空文字）
+	PriceDate string
 }
+
+// 取引の登録結果（約定済みの場合は取引データ、価格が未公表の場合は未約定の注文）
+type AssetBuyResult struct {
+	AssetBuy   *AssetBuy   `json:",omitempty"`
+	AssetOrder *AssetOrder `json:",omitempty"`
+	// 試算のみで保存していない場合は true
+	DryRun bool
+}
+
 type AssetBuyReq struct {
 	AssetCode string `json:"AssetCode"`
-	Date      string `json:"Date"`
-	Unit      int    `json:"Unit"`
-	Amount    int    `json:"Amount"`
+	// 注文日
+	Date      string          `json:"Date"`
+	TradeType int             `json:"TradeType"`
+	Unit      decimal.Decimal `json:"Unit"`
+	Amount    decimal.Decimal `json:"Amount"`
+	// 積立プランの実行時のみ設定する
+	PlanId string `json:"-"`
+}
+
+// 取引データの部分更新（指定された項目のみ、再計算せずにそのまま変更する）
+type AssetBuyPatchReq struct {
+	// 約定日
+	Date      *string          `json:"Date"`
+	TradeType *int             `json:"TradeType"`
+	Unit      *decimal.Decimal `json:"Unit"`
+	Amount    *decimal.Decimal `json:"Amount"`
 }
 
 /*
- * 指定した資産コードまたはカテゴリーIDを元に資産マスタデータを取得
+ * 指定した資産コードの取引データを取得（資産コードの指定がない場合は全件）
  */
 func GetAssetBuyByAssetCode(assetCode string) ([]AssetBuy, error) {
-	var assetBuy []AssetBuy
-	// Dynamodb接続
-	table := connectDynamodb("asset_unit")
-	filter := table.Scan()
-	// 取得条件設定
-	if assetCode != "" {
-		filter = filter.Filter("'AssetCode' = ?", assetCode)
-	}
+	return repository.AssetBuy.FindByAssetCode(assetCode)
+}
 
-	err := filter.All(&assetBuy)
-	return assetBuy, err
+/*
+ * 指定した約定日の全資産の取引データを取得
+ */
+func GetAssetBuyByDate(date string) ([]AssetBuy, error) {
+	return repository.AssetBuy.FindByDate(date)
 }
 
 /*
- * 購入資産データを保存
+ * 取引データを保存（購入・売却）
+ * 資産マスタの約定日オフセットに従い、注文日から所定の営業日後の価格で約定する
+ * 約定日の価格が未公表の場合は未約定の注文として保存し、価格の公表後に SettleAllAssetOrder で約定する
  */
-func SaveAssetBuy(assetBuyReq *AssetBuyReq) error {
-	assetCode := assetBuyReq.AssetCode
-	date := assetBuyReq.Date
-	amount := float64(assetBuyReq.Amount)
-	unit := float64(assetBuyReq.Unit)
+func SaveAssetBuy(assetBuyReq *AssetBuyReq) (AssetBuyResult, error) {
+	return saveAssetBuy(assetBuyReq, false)
+}
 
-	// 対象日の基準価格を取得
-	priceList, _ := GetAssetPriceByAssetCodeAndDate(assetCode, date, date)
-	price := priceList[0].Price
+/*
+ * 取引データを保存せずに、SaveAssetBuy と同じ計算で約定日・口数・金額を試算
+ * 約定日の価格が未公表の場合は、未約定の注文となることを返す
+ */
+func PreviewAssetBuy(assetBuyReq *AssetBuyReq) (AssetBuyResult, error) {
+	return saveAssetBuy(assetBuyReq, true)
+}
 
-	// 投資信託であれば、基準価格=1万口に合わせて、算出する
-	assetMaster, _ := GetAssetMasterByAssetCodeAndCategoryId(assetCode, "")
-	basePriceConstant := 1
-	if assetMaster[0].Type == config.ASSET_TYPE_INVESTMENT_TRUST {
-		basePriceConstant = 10000
+// 取引データを保存（dryRun が true の場合は保存せずに結果のみ返す）
+func saveAssetBuy(assetBuyReq *AssetBuyReq, dryRun bool) (AssetBuyResult, error) {
+	result := AssetBuyResult{DryRun: dryRun}
+	tradeType, err := validateAssetBuyReq(assetBuyReq)
+	if err != nil {
+		return result, err
+	}
+	assetMaster, err := findTradableAssetMaster(assetBuyReq.AssetCode)
+	if err != nil {
+		return result, err
+	}
+
+	// 注文日を先頭に付与した注文IDを採番する
+	transactionId, err := NewTransactionId(assetBuyReq.Date)
+	if err != nil {
+		return result, err
+	}
+	assetOrder := AssetOrder{AssetCode: assetBuyReq.AssetCode, TransactionId: transactionId, OrderDate: assetBuyReq.Date,
+		TradeType: tradeType, Unit: assetBuyReq.Unit, Amount: assetBuyReq.Amount, PlanId: assetBuyReq.PlanId}
+
+	// 約定日と価格を決める（価格が未公表の場合は未約定の注文として保存する）
+	execution, ok, err := resolveOrderExecution(assetMaster, assetOrder.OrderDate)
+	if err != nil {
+		return result, err
+	}
+	if !ok {
+		if !dryRun {
+			if err := saveAssetOrder(assetOrder); err != nil {
+				return result, err
+			}
+		}
+		result.AssetOrder = &assetOrder
+		return result, nil
+	}
+
+	assetBuy, err := executeAssetOrder(assetOrder, assetMaster, execution, dryRun)
+	if err != nil {
+		return result, err
+	}
+	result.AssetBuy = &assetBuy
+	return result, nil
+}
+
+// 注文を約定日の価格で約定し、取引データとして保存（dryRun が true の場合は保存しない）
+func executeAssetOrder(assetOrder AssetOrder, assetMaster AssetMaster, execution orderExecution, dryRun bool) (AssetBuy, error) {
+	assetBuy, err := newAssetBuy(assetOrder, assetMaster, execution)
+	if err != nil {
+		return assetBuy, err
 	}
+
+	// 売却の場合、約定日時点の保有口数を超えていないか確認する
+	if assetOrder.TradeType == config.TRADE_TYPE_SELL {
+		assetBuyList, err := GetAssetBuyByAssetCode(assetOrder.AssetCode)
+		if err != nil {
+			return assetBuy, err
+		}
+		var assetBuyListUntilDate []AssetBuy
+		for _, data := range assetBuyList {
+			if data.Date <= execution.date {
+				assetBuyListUntilDate = append(assetBuyListUntilDate, data)
+			}
+		}
+		holding, err := CalcAssetHolding(assetBuyListUntilDate, AssetCurrency(assetMaster))
+		if err != nil {
+			return assetBuy, err
+		}
+		if holding.Unit.LessThan(assetBuy.Unit) {
+			return assetBuy, newValidationError("sell unit exceeds holding unit")
+		}
+	}
+
+	if dryRun {
+		return assetBuy, nil
+	}
+
+	// 資産データ登録
+	err = repository.AssetBuy.Save(assetBuy)
+	return assetBuy, err
+}
+
+// 注文を約定日の価格で約定した取引データを生成
+func newAssetBuy(assetOrder AssetOrder, assetMaster AssetMaster, execution orderExecution) (AssetBuy, error) {
+	var assetBuy AssetBuy
+	amount := assetOrder.Amount
+	unit := assetOrder.Unit
+	price := execution.price
+
+	// 投資信託であれば、基準価格=1万口に合わせて、算出する
 	// 金額を引数に口数を計算する
-	if amount != 0 {
-		unit = math.Round(float64(amount) / float64(price) * float64(basePriceConstant))
+	var err error
+	if !amount.IsZero() {
+		if unit, err = CalcUnitByAmount(amount, price, assetMaster); err != nil {
+			return assetBuy, err
+		}
 	}
 	// 口数を引数に金額を計算する
-	if unit != 0 {
-		amount = math.Round(float64(price) * float64(unit) / float64(basePriceConstant))
+	if !unit.IsZero() {
+		if amount, err = CalcPresentValue(price, unit, assetMaster); err != nil {
+			return assetBuy, err
+		}
+	}
+
+	// 同日に複数回取引しても上書きされないよう、取引毎に一意なIDを採番する
+	transactionId, err := NewTransactionId(execution.date)
+	if err != nil {
+		return assetBuy, err
+	}
+	assetBuy = AssetBuy{AssetCode: assetOrder.AssetCode, TransactionId: transactionId, Date: execution.date,
+		OrderDate: assetOrder.OrderDate, TradeType: assetOrder.TradeType, Unit: unit, Amount: amount,
+		PlanId: assetOrder.PlanId, PriceDate: execution.priceDate}
+	return assetBuy, nil
+}
+
+/*
+ * 登録済みの取引データを置き換える（資産コードと取引IDで特定する）
+ * SaveAssetBuy と同じく、注文日から約定日・口数・金額を計算し直す
+ * 約定日が変わった場合は取引IDを採番し直す
+ */
+func UpdateAssetBuy(assetCode string, transactionId string, assetBuyReq *AssetBuyReq) (AssetBuyResult, error) {
+	var result AssetBuyResult
+	current, err := findAssetBuy(assetCode, transactionId)
+	if err != nil {
+		return result, err
+	}
+	if assetBuyReq.AssetCode != "" && assetBuyReq.AssetCode != assetCode {
+		return result, newValidationError("asset code cannot be changed")
+	}
+	tradeType, err := validateAssetBuyReq(assetBuyReq)
+	if err != nil {
+		return result, err
+	}
+	assetMaster, err := findTradableAssetMaster(assetCode)
+	if err != nil {
+		return result, err
+	}
+
+	// 置き換え後の取引は約定済みである必要がある
+	execution, ok, err := resolveOrderExecution(assetMaster, assetBuyReq.Date)
+	if err != nil {
+		return result, err
+	}
+	if !ok {
+		return result, newValidationError("price is not yet available for order date: " + assetBuyReq.Date)
+	}
+	assetOrder := AssetOrder{AssetCode: assetCode, TransactionId: current.TransactionId, OrderDate: assetBuyReq.Date,
+		TradeType: tradeType, Unit: assetBuyReq.Unit, Amount: assetBuyReq.Amount, PlanId: current.PlanId}
+	assetBuy, err := newAssetBuy(assetOrder, assetMaster, execution)
+	if err != nil {
+		return result, err
+	}
+	if assetBuy.Date == current.Date {
+		assetBuy.TransactionId = current.TransactionId
+	}
+
+	if err := replaceAssetBuy(assetMaster, current, &assetBuy); err != nil {
+		return result, err
+	}
+	result.AssetBuy = &assetBuy
+	return result, nil
+}
+
+/*
+ * 登録済みの取引データのうち、指定された項目のみを変更する（口数・金額は再計算しない）
+ * 約定日が変わった場合は取引IDを採番し直す
+ */
+func PatchAssetBuy(assetCode string, transactionId string, assetBuyPatchReq *AssetBuyPatchReq) (AssetBuy, error) {
+	assetBuy, err := findAssetBuy(assetCode, transactionId)
+	if err != nil {
+		return assetBuy, err
+	}
+	current := assetBuy
+	if assetBuyPatchReq.Date != nil {
+		if _, err := time.Parse(dateLayout, *assetBuyPatchReq.Date); err != nil {
+			return current, newValidationError("invalid date: " + *assetBuyPatchReq.Date)
+		}
+		assetBuy.Date = *assetBuyPatchReq.Date
+	}
+	if assetBuyPatchReq.TradeType != nil {
+		if *assetBuyPatchReq.TradeType != config.TRADE_TYPE_BUY && *assetBuyPatchReq.TradeType != config.TRADE_TYPE_SELL {
+			return current, newValidationError("invalid trade type")
+		}
+		assetBuy.TradeType = *assetBuyPatchReq.TradeType
+	}
+	if assetBuyPatchReq.Unit != nil {
+		assetBuy.Unit = *assetBuyPatchReq.Unit
+	}
+	if assetBuyPatchReq.Amount != nil {
+		assetBuy.Amount = *assetBuyPatchReq.Amount
+	}
+	if assetBuy.Amount.Sign() < 0 || assetBuy.Unit.Sign() < 0 {
+		return current, newValidationError("amount and unit must not be negative")
+	}
+
+	assetMaster, err := findTradableAssetMaster(assetCode)
+	if err != nil {
+		return current, err
+	}
+	if assetBuy.Date != current.Date {
+		assetBuy.TransactionId, err = NewTransactionId(assetBuy.Date)
+		if err != nil {
+			return current, err
+		}
+	}
+	if err := replaceAssetBuy(assetMaster, current, &assetBuy); err != nil {
+		return current, err
+	}
+	return assetBuy, nil
+}
+
+/*
+ * 取引データを削除
+ * 削除により保有口数を超える売却が生じる場合は削除できない
+ */
+func DeleteAssetBuy(assetCode string, transactionId string) error {
+	current, err := findAssetBuy(assetCode, transactionId)
+	if err != nil {
+		return err
+	}
+	assetMaster, err := findAssetMaster(assetCode)
+	if err != nil {
+		return err
+	}
+	return replaceAssetBuy(assetMaster, current, nil)
+}
+
+// 取引データを置き換える（assetBuy が nil の場合は削除のみ）
+// 置き換え後の全取引で、保有口数を超える売却がないか確認してから保存する
+func replaceAssetBuy(assetMaster AssetMaster, current AssetBuy, assetBuy *AssetBuy) error {
+	assetBuyList, err := GetAssetBuyByAssetCode(current.AssetCode)
+	if err != nil {
+		return err
+	}
+	var replacedList []AssetBuy
+	for _, data := range assetBuyList {
+		if data.TransactionId != current.TransactionId {
+			replacedList = append(replacedList, data)
+		}
+	}
+	if assetBuy != nil {
+		replacedList = append(replacedList, *assetBuy)
+	}
+	if err := validateAssetBuyHistory(replacedList, AssetCurrency(assetMaster)); err != nil {
+		return err
 	}
 
-	assetAmount := AssetBuy{AssetCode: assetCode, Date: date, Unit: int(unit), Amount: int(amount)}
+	// 新しい取引を保存してから、取引IDが変わった場合に元の取引を削除する
+	if assetBuy != nil {
+		if err := repository.AssetBuy.Save(*assetBuy); err != nil {
+			return err
+		}
+		if assetBuy.TransactionId == current.TransactionId {
+			return nil
+		}
+	}
+	return repository.AssetBuy.Delete(current.AssetCode, current.TransactionId)
+}
+
+// 取引日順に保有口数を追い、保有口数を超える売却がないか確認
+func validateAssetBuyHistory(assetBuyList []AssetBuy, currency string) error {
+	var holding AssetHolding
+	for _, data := range sortAssetBuyList(assetBuyList) {
+		if data.TradeType == config.TRADE_TYPE_SELL && holding.Unit.LessThan(data.Unit) {
+			return newValidationError("sell unit exceeds holding unit on " + data.Date)
+		}
+		var err error
+		if holding, err = applyAssetBuy(holding, data, currency); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 資産コードと取引IDに一致する取引データを取得（未登録の場合は入力誤りとする）
+func findAssetBuy(assetCode string, transactionId string) (AssetBuy, error) {
+	if assetCode == "" || transactionId == "" {
+		return AssetBuy{}, newValidationError("asset code and transaction id are required")
+	}
+	assetBuyList, err := GetAssetBuyByAssetCode(assetCode)
+	if err != nil {
+		return AssetBuy{}, err
+	}
+	for _, data := range assetBuyList {
+		if data.TransactionId == transactionId {
+			return data, nil
+		}
+	}
+	return AssetBuy{}, newValidationError("transaction not found: " + transactionId)
+}
+
+// 取引の入力内容を確認し、取引種別を返す（指定がなければ購入として扱う）
+func validateAssetBuyReq(assetBuyReq *AssetBuyReq) (int, error) {
+	tradeType := assetBuyReq.TradeType
+	if tradeType == 0 {
+		tradeType = config.TRADE_TYPE_BUY
+	}
+	if tradeType != config.TRADE_TYPE_BUY && tradeType != config.TRADE_TYPE_SELL {
+		return tradeType, newValidationError("invalid trade type")
+	}
+	if _, err := time.Parse(dateLayout, assetBuyReq.Date); err != nil {
+		return tradeType, newValidationError("invalid date: " + assetBuyReq.Date)
+	}
+	if assetBuyReq.Amount.Sign() < 0 || assetBuyReq.Unit.Sign() < 0 {
+		return tradeType, newValidationError("amount and unit must not be negative")
+	}
+	if assetBuyReq.Amount.IsZero() && assetBuyReq.Unit.IsZero() {
+		return tradeType, newValidationError("amount or unit is required")
+	}
+	return tradeType, nil
+}
+
+// 取引できる資産の資産マスタを取得（指数（ベンチマーク）は保有できない）
+func findTradableAssetMaster(assetCode string) (AssetMaster, error) {
+	assetMaster, err := findAssetMaster(assetCode)
+	if err != nil {
+		return assetMaster, err
+	}
+	if assetMaster.Type == config.ASSET_TYPE_INDEX {
+		return assetMaster, newValidationError("index asset cannot be traded: " + assetCode)
+	}
+	return assetMaster, nil
+}
+
+/*
+ * 旧形式（AssetCode + Date をキーとする）の取引テーブルから、取引IDを採番して asset_unit へ移行
+ * 移行件数を返す
+ */
+func MigrateAssetUnit(sourceTableName string) (int, error) {
+	var legacyAssetBuyList []AssetBuy
 	// Dynamodb接続
-	table := connectDynamodb("asset_unit")
-	// 資産データ登録
-	err := table.Put(assetAmount).Run()
+	sourceTable := connectDynamodb(sourceTableName)
+	if err := sourceTable.Scan().All(&legacyAssetBuyList); err != nil {
+		return 0, err
+	}
 
-	return err
+	count := 0
+	for _, data := range legacyAssetBuyList {
+		// 移行済みのデータは対象外
+		if data.TransactionId != "" {
+			continue
+		}
+		transactionId, err := NewTransactionId(data.Date)
+		if err != nil {
+			return count, err
+		}
+		data.TransactionId = transactionId
+		if data.TradeType == 0 {
+			data.TradeType = config.TRADE_TYPE_BUY
+		}
+		if err := repository.AssetBuy.Save(data); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
 }
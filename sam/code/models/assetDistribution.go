@@ -0,0 +1,262 @@
+package models
+
+import (
+	"code/config"
+	"code/decimal"
+	"time"
+)
+
+// 配当金・分配金（金額は資産の通貨建て）
+type AssetDistribution struct {
+	AssetCode     string
+	TransactionId string
+	// 受取日
+	Date string
+	// 1口あたりの金額（投資信託は1万口あたり）
+	AmountPerUnit decimal.Decimal
+	// 対象口数
+	Unit decimal.Decimal
+	// 受取金額
+	Amount decimal.Decimal
+}
+type AssetDistributionReq struct {
+	AssetCode     string          `json:"AssetCode"`
+	Date          string          `json:"Date"`
+	AmountPerUnit decimal.Decimal `json:"AmountPerUnit"`
+	// 指定がない場合は受取日時点の保有口数
+	Unit decimal.Decimal `json:"Unit"`
+	// 指定がない場合は1口あたりの金額と対象口数から算出（税引後の受取額を登録する場合に指定）
+	Amount decimal.Decimal `json:"Amount"`
+}
+
+// 配当金・分配金の部分更新（指定された項目のみ、再計算せずにそのまま変更する）
+type AssetDistributionPatchReq struct {
+	// 受取日
+	Date          *string          `json:"Date"`
+	AmountPerUnit *decimal.Decimal `json:"AmountPerUnit"`
+	Unit          *decimal.Decimal `json:"Unit"`
+	Amount        *decimal.Decimal `json:"Amount"`
+}
+
+/*
+ * 指定した資産コードの配当金・分配金を取得（資産コードの指定がない場合は全件）
+ */
+func GetAssetDistributionList(assetCode string) ([]AssetDistribution, error) {
+	return repository.AssetDistribution.FindByAssetCode(assetCode)
+}
+
+/*
+ * 配当金・分配金を保存
+ * 対象口数の指定がない場合は受取日時点の保有口数、受取金額の指定がない場合は1口あたりの金額から算出する
+ */
+func SaveAssetDistribution(assetDistributionReq *AssetDistributionReq) (AssetDistribution, error) {
+	assetDistribution, err := newAssetDistribution(assetDistributionReq)
+	if err != nil {
+		return assetDistribution, err
+	}
+	if assetDistribution.TransactionId, err = NewTransactionId(assetDistribution.Date); err != nil {
+		return assetDistribution, err
+	}
+	err = repository.AssetDistribution.Save(assetDistribution)
+	return assetDistribution, err
+}
+
+/*
+ * 登録済みの配当金・分配金を置き換える（資産コードと取引IDで特定する）
+ * SaveAssetDistribution と同じく、対象口数・受取金額の指定がない場合は算出し直す
+ * 受取日が変わった場合は取引IDを採番し直す
+ */
+func UpdateAssetDistribution(assetCode string, transactionId string, assetDistributionReq *AssetDistributionReq) (AssetDistribution, error) {
+	current, err := findAssetDistribution(assetCode, transactionId)
+	if err != nil {
+		return AssetDistribution{}, err
+	}
+	if assetDistributionReq.AssetCode != "" && assetDistributionReq.AssetCode != assetCode {
+		return AssetDistribution{}, newValidationError("asset code cannot be changed")
+	}
+	req := *assetDistributionReq
+	req.AssetCode = assetCode
+	assetDistribution, err := newAssetDistribution(&req)
+	if err != nil {
+		return assetDistribution, err
+	}
+	assetDistribution.TransactionId = current.TransactionId
+	if assetDistribution.Date != current.Date {
+		if assetDistribution.TransactionId, err = NewTransactionId(assetDistribution.Date); err != nil {
+			return assetDistribution, err
+		}
+	}
+	err = replaceAssetDistribution(current, &assetDistribution)
+	return assetDistribution, err
+}
+
+/*
+ * 登録済みの配当金・分配金のうち、指定された項目のみを変更する（対象口数・受取金額は再計算しない）
+ * 受取日が変わった場合は取引IDを採番し直す
+ */
+func PatchAssetDistribution(assetCode string, transactionId string, assetDistributionPatchReq *AssetDistributionPatchReq) (AssetDistribution, error) {
+	assetDistribution, err := findAssetDistribution(assetCode, transactionId)
+	if err != nil {
+		return assetDistribution, err
+	}
+	current := assetDistribution
+	if assetDistributionPatchReq.Date != nil {
+		if _, err := time.Parse(dateLayout, *assetDistributionPatchReq.Date); err != nil {
+			return current, newValidationError("invalid date: " + *assetDistributionPatchReq.Date)
+		}
+		assetDistribution.Date = *assetDistributionPatchReq.Date
+	}
+	if assetDistributionPatchReq.AmountPerUnit != nil {
+		assetDistribution.AmountPerUnit = *assetDistributionPatchReq.AmountPerUnit
+	}
+	if assetDistributionPatchReq.Unit != nil {
+		assetDistribution.Unit = *assetDistributionPatchReq.Unit
+	}
+	if assetDistributionPatchReq.Amount != nil {
+		assetDistribution.Amount = *assetDistributionPatchReq.Amount
+	}
+	if assetDistribution.AmountPerUnit.Sign() < 0 || assetDistribution.Unit.Sign() < 0 || assetDistribution.Amount.Sign() < 0 {
+		return current, newValidationError("amount and unit must not be negative")
+	}
+
+	if assetDistribution.Date != current.Date {
+		if assetDistribution.TransactionId, err = NewTransactionId(assetDistribution.Date); err != nil {
+			return current, err
+		}
+	}
+	if err := replaceAssetDistribution(current, &assetDistribution); err != nil {
+		return current, err
+	}
+	return assetDistribution, nil
+}
+
+/*
+ * 配当金・分配金を削除
+ */
+func DeleteAssetDistribution(assetCode string, transactionId string) error {
+	current, err := findAssetDistribution(assetCode, transactionId)
+	if err != nil {
+		return err
+	}
+	return replaceAssetDistribution(current, nil)
+}
+
+/*
+ * 配当金・分配金の合計
+ */
+func SumAssetDistribution(assetDistributionList []AssetDistribution) decimal.Decimal {
+	total := decimal.Zero
+	for _, data := range assetDistributionList {
+		total = total.Add(data.Amount)
+	}
+	return total
+}
+
+/*
+ * 配当金・分配金の受取金額を受取日の為替レートで基準通貨に換算
+ */
+func ConvertAssetDistributionList(assetDistributionList []AssetDistribution, fxRateList FxRateList) ([]AssetDistribution, error) {
+	convertedList := make([]AssetDistribution, len(assetDistributionList))
+	for idx, data := range assetDistributionList {
+		amount, err := fxRateList.Convert(data.Amount, data.Date)
+		if err != nil {
+			return nil, err
+		}
+		data.Amount = amount
+		convertedList[idx] = data
+	}
+	return convertedList, nil
+}
+
+// 配当金・分配金を置き換える（assetDistribution が nil の場合は削除のみ）
+func replaceAssetDistribution(current AssetDistribution, assetDistribution *AssetDistribution) error {
+	// 新しいデータを保存してから、取引IDが変わった場合に元のデータを削除する
+	if assetDistribution != nil {
+		if err := repository.AssetDistribution.Save(*assetDistribution); err != nil {
+			return err
+		}
+		if assetDistribution.TransactionId == current.TransactionId {
+			return nil
+		}
+	}
+	return repository.AssetDistribution.Delete(current.AssetCode, current.TransactionId)
+}
+
+// 資産コードと取引IDに一致する登録済みの配当金・分配金を取得（未登録の場合は入力誤りとする）
+func findAssetDistribution(assetCode string, transactionId string) (AssetDistribution, error) {
+	if assetCode == "" || transactionId == "" {
+		return AssetDistribution{}, newValidationError("asset code and transaction id are required")
+	}
+	assetDistributionList, err := GetAssetDistributionList(assetCode)
+	if err != nil {
+		return AssetDistribution{}, err
+	}
+	for _, data := range assetDistributionList {
+		if data.TransactionId == transactionId {
+			return data, nil
+		}
+	}
+	return AssetDistribution{}, newValidationError("distribution not found: " + transactionId)
+}
+
+// 入力内容を確認して配当金・分配金を生成（取引IDは設定しない）
+func newAssetDistribution(assetDistributionReq *AssetDistributionReq) (AssetDistribution, error) {
+	var assetDistribution AssetDistribution
+	date := assetDistributionReq.Date
+	if _, err := time.Parse(dateLayout, date); err != nil {
+		return assetDistribution, newValidationError("invalid date: " + date)
+	}
+	if assetDistributionReq.AmountPerUnit.Sign() < 0 || assetDistributionReq.Unit.Sign() < 0 || assetDistributionReq.Amount.Sign() < 0 {
+		return assetDistribution, newValidationError("amount and unit must not be negative")
+	}
+	if assetDistributionReq.AmountPerUnit.IsZero() && assetDistributionReq.Amount.IsZero() {
+		return assetDistribution, newValidationError("amount per unit or amount is required")
+	}
+
+	assetMaster, err := GetAssetMasterByAssetCodeAndCategoryId(assetDistributionReq.AssetCode, "")
+	if err != nil {
+		return assetDistribution, err
+	}
+	if len(assetMaster) == 0 {
+		return assetDistribution, newValidationError("asset master not found: " + assetDistributionReq.AssetCode)
+	}
+	if assetMaster[0].Type == config.ASSET_TYPE_INDEX || assetMaster[0].Type == config.ASSET_TYPE_CACHE {
+		return assetDistribution, newValidationError("asset has no distribution: " + assetDistributionReq.AssetCode)
+	}
+
+	// 対象口数の指定がなければ受取日時点の保有口数とする
+	unit := assetDistributionReq.Unit
+	if unit.IsZero() {
+		assetBuyList, err := GetAssetBuyByAssetCode(assetDistributionReq.AssetCode)
+		if err != nil {
+			return assetDistribution, err
+		}
+		var assetBuyListUntilDate []AssetBuy
+		for _, data := range assetBuyList {
+			if data.Date <= date {
+				assetBuyListUntilDate = append(assetBuyListUntilDate, data)
+			}
+		}
+		holding, err := CalcAssetHolding(assetBuyListUntilDate, AssetCurrency(assetMaster[0]))
+		if err != nil {
+			return assetDistribution, err
+		}
+		unit = holding.Unit
+	}
+	if unit.IsZero() {
+		return assetDistribution, newValidationError("no holding on " + date + " for asset: " + assetDistributionReq.AssetCode)
+	}
+
+	// 受取金額の指定がなければ1口あたりの金額から算出する（投資信託は1万口あたり）
+	amount := assetDistributionReq.Amount
+	if amount.IsZero() {
+		var err error
+		if amount, err = CalcPresentValue(assetDistributionReq.AmountPerUnit, unit, assetMaster[0]); err != nil {
+			return assetDistribution, err
+		}
+	}
+
+	assetDistribution = AssetDistribution{AssetCode: assetDistributionReq.AssetCode, Date: date,
+		AmountPerUnit: assetDistributionReq.AmountPerUnit, Unit: unit, Amount: amount}
+	return assetDistribution, nil
+}
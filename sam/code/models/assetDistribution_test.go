@@ -0,0 +1,67 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+// 配当金・分配金の置き換え・部分更新・削除
+func TestUpdateAssetDistribution(t *testing.T) {
+	defer SetRepository(SetRepository(NewMemoryRepository()))
+	saveTestAssetMasterAndPrice(t)
+	saveTestStockTrade(t)
+
+	// 09/22 時点の保有200株 × 1株あたり15円
+	saved, err := SaveAssetDistribution(&AssetDistributionReq{AssetCode: "7203.T", Date: "2021-09-22", AmountPerUnit: mustDecimal(t, "15")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Unit.String() != "200" || saved.Amount.String() != "3000" {
+		t.Errorf("SaveAssetDistribution = %+v, want 200 units, 3000", saved)
+	}
+
+	// 受取日を変更すると保有口数を算出し直し、取引IDを採番し直す
+	updated, err := UpdateAssetDistribution("7203.T", saved.TransactionId,
+		&AssetDistributionReq{Date: "2021-09-24", AmountPerUnit: mustDecimal(t, "15")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.TransactionId == saved.TransactionId || updated.Unit.String() != "100" || updated.Amount.String() != "1500" {
+		t.Errorf("UpdateAssetDistribution = %+v, want new id with 100 units, 1500", updated)
+	}
+
+	// 税引後の受取額のみ変更する
+	amount := mustDecimal(t, "1195")
+	patched, err := PatchAssetDistribution("7203.T", updated.TransactionId, &AssetDistributionPatchReq{Amount: &amount})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patched.TransactionId != updated.TransactionId || patched.Unit.String() != "100" || patched.Amount.String() != "1195" {
+		t.Errorf("PatchAssetDistribution = %+v, want same id with amount 1195", patched)
+	}
+	distributionList, err := GetAssetDistributionList("7203.T")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(distributionList) != 1 || distributionList[0].Amount.String() != "1195" {
+		t.Errorf("distribution = %+v, want 1 with amount 1195", distributionList)
+	}
+
+	var validationError *ValidationError
+	if _, err := UpdateAssetDistribution("7203.T", saved.TransactionId,
+		&AssetDistributionReq{Date: "2021-09-24", AmountPerUnit: mustDecimal(t, "15")}); !errors.As(err, &validationError) {
+		t.Errorf("update deleted id: error = %v, want ValidationError", err)
+	}
+	if _, err := UpdateAssetDistribution("7203.T", updated.TransactionId,
+		&AssetDistributionReq{AssetCode: "FUND", Date: "2021-09-24", AmountPerUnit: mustDecimal(t, "15")}); !errors.As(err, &validationError) {
+		t.Errorf("change asset code: error = %v, want ValidationError", err)
+	}
+
+	// 削除後は一覧に含まれない
+	if err := DeleteAssetDistribution("7203.T", updated.TransactionId); err != nil {
+		t.Fatal(err)
+	}
+	if distributionList, _ := GetAssetDistributionList("7203.T"); len(distributionList) != 0 {
+		t.Errorf("distribution = %+v, want none", distributionList)
+	}
+}
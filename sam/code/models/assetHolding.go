@@ -0,0 +1,132 @@
+package models
+
+import (
+	"code/config"
+	"code/decimal"
+	"sort"
+)
+
+// 保有資産の状況（移動平均法で取得価額を算出）
+type AssetHolding struct {
+	// 保有口数
+	Unit decimal.Decimal
+	// 保有分の取得価額
+	Amount decimal.Decimal
+	// 実現損益
+	RealizedProfit decimal.Decimal
+}
+
+// 取引日毎の保有資産の状況の推移（取引日順）
+type AssetHoldingHistory struct {
+	dateList    []string
+	holdingList []AssetHolding
+}
+
+/*
+ * 取引データから保有口数、取得価額、実現損益を算出
+ * 取得価額は移動平均法で算出し、売却時は売却口数分の取得価額を差し引く
+ * currency は取引データの金額の通貨（基準通貨に換算済みの場合は基準通貨）
+ */
+func CalcAssetHolding(assetBuyList []AssetBuy, currency string) (AssetHolding, error) {
+	var holding AssetHolding
+	for _, data := range sortAssetBuyList(assetBuyList) {
+		var err error
+		if holding, err = applyAssetBuy(holding, data, currency); err != nil {
+			return holding, err
+		}
+	}
+	return holding, nil
+}
+
+/*
+ * 取引データを取引日順に再生し、取引日毎の保有状況の推移を算出
+ */
+func CalcAssetHoldingHistory(assetBuyList []AssetBuy, currency string) (AssetHoldingHistory, error) {
+	var history AssetHoldingHistory
+	var holding AssetHolding
+	for _, data := range sortAssetBuyList(assetBuyList) {
+		var err error
+		if holding, err = applyAssetBuy(holding, data, currency); err != nil {
+			return history, err
+		}
+		// 同日の取引は最後の取引後の状況で上書きする
+		last := len(history.dateList) - 1
+		if last >= 0 && history.dateList[last] == data.Date {
+			history.holdingList[last] = holding
+			continue
+		}
+		history.dateList = append(history.dateList, data.Date)
+		history.holdingList = append(history.holdingList, holding)
+	}
+	return history, nil
+}
+
+/*
+ * 指定日時点（指定日の取引を含む）の保有状況を取得
+ */
+func (history AssetHoldingHistory) HoldingAt(date string) AssetHolding {
+	// 指定日より後の最初の取引日の位置
+	idx := sort.Search(len(history.dateList), func(i int) bool { return history.dateList[i] > date })
+	if idx == 0 {
+		return AssetHolding{}
+	}
+	return history.holdingList[idx-1]
+}
+
+/*
+ * 全取引を反映した現在の保有状況を取得
+ */
+func (history AssetHoldingHistory) Latest() AssetHolding {
+	if len(history.holdingList) == 0 {
+		return AssetHolding{}
+	}
+	return history.holdingList[len(history.holdingList)-1]
+}
+
+/*
+ * 最初の取引日（取引がない場合は空文字）
+ */
+func (history AssetHoldingHistory) FirstDate() string {
+	if len(history.dateList) == 0 {
+		return ""
+	}
+	return history.dateList[0]
+}
+
+// 取引日順に並べ替え（同日の場合は購入を先に処理する）
+func sortAssetBuyList(assetBuyList []AssetBuy) []AssetBuy {
+	sortedList := make([]AssetBuy, len(assetBuyList))
+	copy(sortedList, assetBuyList)
+	sort.SliceStable(sortedList, func(i, j int) bool {
+		if sortedList[i].Date != sortedList[j].Date {
+			return sortedList[i].Date < sortedList[j].Date
+		}
+		return sortedList[i].TradeType < sortedList[j].TradeType
+	})
+	return sortedList
+}
+
+// 1件の取引を保有状況に反映
+func applyAssetBuy(holding AssetHolding, data AssetBuy, currency string) (AssetHolding, error) {
+	if data.TradeType != config.TRADE_TYPE_SELL {
+		holding.Unit = holding.Unit.Add(data.Unit)
+		holding.Amount = holding.Amount.Add(data.Amount)
+		return holding, nil
+	}
+	if holding.Unit.IsZero() {
+		return holding, nil
+	}
+	// 売却口数分の取得価額
+	sellCost, err := holding.Amount.Mul(data.Unit)
+	if err != nil {
+		return holding, err
+	}
+	if sellCost, err = sellCost.Div(holding.Unit); err != nil {
+		return holding, err
+	}
+	sellCost = RoundAmount(sellCost, currency)
+	holding.RealizedProfit = holding.RealizedProfit.Add(data.Amount.Sub(sellCost))
+	holding.Unit = holding.Unit.Sub(data.Unit)
+	holding.Amount = holding.Amount.Sub(sellCost)
+	return holding, nil
+}
@@ -0,0 +1,215 @@
+package models
+
+import (
+	"code/config"
+	"code/decimal"
+	"log"
+	"sort"
+	"strconv"
+)
+
+// 保有資産の一覧とカテゴリー毎の集計
+type AssetHoldingSummary struct {
+	Detail   []AssetHoldingDetail
+	Category [8]AssetHoldingCategory
+}
+
+// 資産毎の保有状況（金額は基準通貨。株価と平均購入単価は資産の通貨建て）
+type AssetHoldingDetail struct {
+	AssetCode                     string
+	AssetName                     string
+	Currency                      string
+	PresentValue                  decimal.Decimal
+	PresentValueDayBeforeProfit   decimal.Decimal
+	TotalUnit                     decimal.Decimal
+	StockPrice                    decimal.Decimal
+	StockPriceDayBeforeProfit     decimal.Decimal
+	StockPriceDayBeforeProfitRate float64
+	TotalBuyPrice                 decimal.Decimal
+	AvaregeUnitPrice              decimal.Decimal
+	RealizedProfit                decimal.Decimal
+	UnrealizedProfit              decimal.Decimal
+	Income                        decimal.Decimal
+}
+
+// カテゴリー毎の保有状況（基準通貨）
+type AssetHoldingCategory struct {
+	AssetCode        string
+	AssetName        string
+	PresentValue     decimal.Decimal
+	TotalBuyPrice    decimal.Decimal
+	RealizedProfit   decimal.Decimal
+	UnrealizedProfit decimal.Decimal
+	Income           decimal.Decimal
+}
+
+/*
+ * 取引データから資産毎の保有状況とカテゴリー毎の集計を算出
+ * 現金以外は最新の価格で、現金は date（当日）の為替レートで評価する
+ * 資産マスタまたは価格のない資産は評価できないため除く
+ */
+func CalcAssetHoldingSummary(assetBuyList []AssetBuy, date string) (AssetHoldingSummary, error) {
+	var summary AssetHoldingSummary
+	// カテゴリコードとカテゴリー名を設定する
+	for code, name := range config.ASSET_CATEGORY_LIST {
+		summary.Category[code-1].AssetCode = strconv.Itoa(code)
+		summary.Category[code-1].AssetName = name
+	}
+
+	// AssetCode毎にリストを格納
+	assetBuyListByAssetCode := make(map[string][]AssetBuy)
+	var assetCodeList []string
+	for _, data := range assetBuyList {
+		if _, ok := assetBuyListByAssetCode[data.AssetCode]; !ok {
+			assetCodeList = append(assetCodeList, data.AssetCode)
+		}
+		assetBuyListByAssetCode[data.AssetCode] = append(assetBuyListByAssetCode[data.AssetCode], data)
+	}
+	sort.Strings(assetCodeList)
+
+	// 通貨毎の為替レート
+	fxRateListByCurrency := make(map[string]FxRateList)
+	for _, assetCode := range assetCodeList {
+		assetMaster, err := GetAssetMasterByAssetCodeAndCategoryId(assetCode, "")
+		if err != nil {
+			return summary, err
+		}
+		// 資産マスタが削除された資産は集計できないため除く
+		if len(assetMaster) == 0 {
+			log.Printf("asset master not found: %s", assetCode)
+			continue
+		}
+
+		// 資産の通貨から基準通貨への為替レートを取得
+		currency := AssetCurrency(assetMaster[0])
+		fxRateList, ok := fxRateListByCurrency[currency]
+		if !ok {
+			fxRateList, err = GetFxRateList(currency)
+			if err != nil {
+				return summary, err
+			}
+			fxRateListByCurrency[currency] = fxRateList
+		}
+
+		detail, ok, err := calcAssetHoldingDetail(assetBuyListByAssetCode[assetCode], assetMaster[0], fxRateList, date)
+		if err != nil {
+			return summary, err
+		}
+		if !ok {
+			continue
+		}
+		summary.Detail = append(summary.Detail, detail)
+
+		// 資産タイプ毎にまとめる（カテゴリーに属さない資産は除く）
+		categoryId, _ := strconv.Atoi(assetMaster[0].CategoryId)
+		index := categoryId - 1
+		if index < 0 || index >= len(summary.Category) {
+			continue
+		}
+		category := &summary.Category[index]
+		category.PresentValue = category.PresentValue.Add(detail.PresentValue)
+		category.TotalBuyPrice = category.TotalBuyPrice.Add(detail.TotalBuyPrice)
+		category.RealizedProfit = category.RealizedProfit.Add(detail.RealizedProfit)
+		category.UnrealizedProfit = category.UnrealizedProfit.Add(detail.UnrealizedProfit)
+		category.Income = category.Income.Add(detail.Income)
+	}
+	return summary, nil
+}
+
+// 資産の保有状況を算出（価格が未取得で評価できない場合は false を返す）
+func calcAssetHoldingDetail(assetBuyList []AssetBuy, assetMaster AssetMaster, fxRateList FxRateList, date string) (AssetHoldingDetail, bool, error) {
+	currency := AssetCurrency(assetMaster)
+	detail := AssetHoldingDetail{AssetCode: assetMaster.AssetCode, AssetName: assetMaster.Name, Currency: currency}
+
+	// 取得価額・実現損益は取引日の為替レートで基準通貨に換算して算出する
+	convertedList, err := ConvertAssetBuyList(assetBuyList, fxRateList)
+	if err != nil {
+		return detail, false, err
+	}
+	holding, err := CalcAssetHolding(convertedList, BaseCurrency())
+	if err != nil {
+		return detail, false, err
+	}
+	detail.TotalUnit = holding.Unit
+	detail.TotalBuyPrice = holding.Amount
+	detail.RealizedProfit = holding.RealizedProfit
+
+	// 資産タイプが現金とそれ以外の場合で算出方法を分ける
+	if assetMaster.Type != config.ASSET_TYPE_CACHE {
+		// 指定した資産の直近価格を取得
+		priceList, err := GetAssetPriceByAssetCodeAndDate(assetMaster.AssetCode, "", "")
+		if err != nil {
+			return detail, false, err
+		}
+		// 価格が未取得の資産は評価できないため除く
+		if len(priceList) == 0 {
+			log.Printf("price not found: %s", assetMaster.AssetCode)
+			return detail, false, nil
+		}
+		latestPrice := priceList[len(priceList)-1]
+		if detail.PresentValue, err = presentValueAt(latestPrice, holding.Unit, assetMaster, fxRateList); err != nil {
+			return detail, false, err
+		}
+		detail.StockPrice = latestPrice.Price
+		// 前日比は前日の価格がある場合のみ算出する
+		if len(priceList) >= 2 {
+			beforeDayPrice := priceList[len(priceList)-2]
+			// 最新の価格の日付より前の取引による保有口数
+			var assetBuyListBeforeLatestDay []AssetBuy
+			for _, data := range assetBuyList {
+				if data.Date < latestPrice.Date {
+					assetBuyListBeforeLatestDay = append(assetBuyListBeforeLatestDay, data)
+				}
+			}
+			holdingBeforeLatestDay, err := CalcAssetHolding(assetBuyListBeforeLatestDay, currency)
+			if err != nil {
+				return detail, false, err
+			}
+			presentValueBeforeDay, err := presentValueAt(beforeDayPrice, holdingBeforeLatestDay.Unit, assetMaster, fxRateList)
+			if err != nil {
+				return detail, false, err
+			}
+			detail.PresentValueDayBeforeProfit = detail.PresentValue.Sub(presentValueBeforeDay)
+			detail.StockPriceDayBeforeProfit = latestPrice.Price.Sub(beforeDayPrice.Price)
+			if latestPrice.Price.Sign() > 0 {
+				detail.StockPriceDayBeforeProfitRate = detail.StockPriceDayBeforeProfit.Float64() / latestPrice.Price.Float64() * 100
+			}
+		}
+		// 平均購入単価（資産の通貨建て）
+		localHolding, err := CalcAssetHolding(assetBuyList, currency)
+		if err != nil {
+			return detail, false, err
+		}
+		if detail.AvaregeUnitPrice, err = CalcAverageUnitPrice(localHolding.Amount, localHolding.Unit, assetMaster); err != nil {
+			return detail, false, err
+		}
+		// 含み損益（現金は損益なし）
+		detail.UnrealizedProfit = detail.PresentValue.Sub(holding.Amount)
+	} else {
+		// 現金の場合、価格一覧を参照せずに指定日（指定日のレートがなければ直近）の為替レートで評価額を算出する
+		if detail.PresentValue, err = fxRateList.Convert(holding.Unit, date); err != nil {
+			return detail, false, err
+		}
+	}
+
+	// 受取済みの配当金・分配金（受取日の為替レートで基準通貨に換算）
+	distributionList, err := GetAssetDistributionList(assetMaster.AssetCode)
+	if err != nil {
+		return detail, false, err
+	}
+	convertedDistributionList, err := ConvertAssetDistributionList(distributionList, fxRateList)
+	if err != nil {
+		return detail, false, err
+	}
+	detail.Income = SumAssetDistribution(convertedDistributionList)
+	return detail, true, nil
+}
+
+// 指定した価格の日付の為替レートで、基準通貨の評価額を算出
+func presentValueAt(price AssetDaily, unit decimal.Decimal, assetMaster AssetMaster, fxRateList FxRateList) (decimal.Decimal, error) {
+	value, err := CalcPresentValue(price.Price, unit, assetMaster)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return fxRateList.Convert(value, price.Date)
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"code/config"
+	"testing"
+)
+
+func TestCalcAssetHoldingSummary(t *testing.T) {
+	defer SetRepository(SetRepository(NewMemoryRepository()))
+	saveTestAssetMasterAndPrice(t)
+	saveTestStockTrade(t)
+	// 資産マスタのない資産と、価格が未取得の資産の取引
+	noPriceMaster := AssetMaster{AssetCode: "NOPRICE", CategoryId: "1", Name: "価格未取得", Type: config.ASSET_TYPE_STOCK,
+		PriceProvider: config.PRICE_PROVIDER_YAHOO_FINANCE, Currency: config.CURRENCY_JPY}
+	if err := repository.AssetMaster.Save(noPriceMaster); err != nil {
+		t.Fatal(err)
+	}
+	for _, assetBuy := range []AssetBuy{
+		{AssetCode: "DELETED", TransactionId: "2021-09-21#01", Date: "2021-09-21", TradeType: config.TRADE_TYPE_BUY,
+			Unit: mustDecimal(t, "10"), Amount: mustDecimal(t, "5000")},
+		{AssetCode: "NOPRICE", TransactionId: "2021-09-21#01", Date: "2021-09-21", TradeType: config.TRADE_TYPE_BUY,
+			Unit: mustDecimal(t, "10"), Amount: mustDecimal(t, "5000")},
+	} {
+		if err := repository.AssetBuy.Save(assetBuy); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assetBuyList, err := GetAssetBuyByAssetCode("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := CalcAssetHoldingSummary(assetBuyList, "2021-09-24")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 評価できない資産は除き、7203.T のみ集計する
+	if len(summary.Detail) != 1 {
+		t.Fatalf("Detail = %+v, want 7203.T only", summary.Detail)
+	}
+	detail := summary.Detail[0]
+	got := []string{detail.AssetCode, detail.TotalUnit.String(), detail.TotalBuyPrice.String(), detail.PresentValue.String(),
+		detail.RealizedProfit.String(), detail.UnrealizedProfit.String(), detail.AvaregeUnitPrice.String()}
+	want := []string{"7203.T", "100", "105000", "120000", "15000", "15000", "1050"}
+	for idx := range want {
+		if got[idx] != want[idx] {
+			t.Errorf("Detail = %v, want %v", got, want)
+			break
+		}
+	}
+	// 前日比は前日（09/22）の価格と09/24より前の取引による保有口数（200株）から算出する
+	if detail.StockPriceDayBeforeProfit.String() != "100" || detail.PresentValueDayBeforeProfit.String() != "-100000" {
+		t.Errorf("day before profit = %s %s, want 100 -100000", detail.StockPriceDayBeforeProfit, detail.PresentValueDayBeforeProfit)
+	}
+	category := summary.Category[0]
+	if category.AssetCode != "1" || category.PresentValue.String() != "120000" || category.TotalBuyPrice.String() != "105000" {
+		t.Errorf("Category[0] = %+v", category)
+	}
+	if !summary.Category[1].PresentValue.IsZero() {
+		t.Errorf("Category[1] = %+v, want zero", summary.Category[1])
+	}
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"code/config"
+	"errors"
+	"testing"
+)
+
+func TestCalcAssetHolding(t *testing.T) {
+	defer SetRepository(SetRepository(NewMemoryRepository()))
+	saveTestAssetMasterAndPrice(t)
+	saveTestStockTrade(t)
+
+	assetBuyList, err := GetAssetBuyByAssetCode("7203.T")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(assetBuyList) != 3 {
+		t.Fatalf("asset buy = %+v, want 3 trades", assetBuyList)
+	}
+	holding, err := CalcAssetHolding(assetBuyList, config.CURRENCY_JPY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 取得価額は移動平均（(100000 + 110000) / 200株 = 1050円）で、売却分を差し引く
+	if holding.Unit.String() != "100" || holding.Amount.String() != "105000" || holding.RealizedProfit.String() != "15000" {
+		t.Errorf("CalcAssetHolding = %+v, want unit 100, amount 105000, realized profit 15000", holding)
+	}
+
+	history, err := CalcAssetHoldingHistory(assetBuyList, config.CURRENCY_JPY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := history.HoldingAt("2021-09-23"); got.Unit.String() != "200" || got.Amount.String() != "210000" {
+		t.Errorf("HoldingAt(2021-09-23) = %+v, want unit 200, amount 210000", got)
+	}
+	if got := history.HoldingAt("2021-09-20"); !got.Unit.IsZero() {
+		t.Errorf("HoldingAt(2021-09-20) = %+v, want empty", got)
+	}
+}
+
+// 保有口数を超える売却は登録できない
+func TestSaveAssetBuySellExceedsHolding(t *testing.T) {
+	defer SetRepository(SetRepository(NewMemoryRepository()))
+	saveTestAssetMasterAndPrice(t)
+	saveTestAssetBuy(t, AssetBuyReq{AssetCode: "7203.T", Date: "2021-09-21", TradeType: config.TRADE_TYPE_BUY, Unit: mustDecimal(t, "10")})
+
+	_, err := SaveAssetBuy(&AssetBuyReq{AssetCode: "7203.T", Date: "2021-09-22", TradeType: config.TRADE_TYPE_SELL, Unit: mustDecimal(t, "11")})
+	var validationError *ValidationError
+	if !errors.As(err, &validationError) {
+		t.Errorf("error = %v, want ValidationError", err)
+	}
+}
@@ -1,59 +1,247 @@
 package models
 
+import (
+	"code/config"
+	"sort"
+	"strings"
+)
+
 type AssetMaster struct {
-	AssetCode  string
-	CategoryId string
-	Name       string
-	Type       int
+	AssetCode     string
+	CategoryId    string
+	Name          string
+	Type          int
+	PriceProvider string
+	Region        string
+	Currency      string
+	// 約定日オフセット（注文日から何営業日後の価格で約定するか。投資信託の T+1 など）
+	NavDateOffset int
+	// 取引日に価格がない場合の価格の決め方（prior: 直前, next: 直後。空文字の場合は直前）
+	PricePolicy string
 }
 
 type AssetMasterReq struct {
-	AssetCode  string `json:"AssetCode"`
-	CategoryId string `json:"CategoryId"`
-	Name       string `json:"Name"`
-	Type       int    `json:"Type"`
+	AssetCode     string `json:"AssetCode"`
+	CategoryId    string `json:"CategoryId"`
+	Name          string `json:"Name"`
+	Type          int    `json:"Type"`
+	PriceProvider string `json:"PriceProvider"`
+	Region        string `json:"Region"`
+	Currency      string `json:"Currency"`
+	NavDateOffset int    `json:"NavDateOffset"`
+	PricePolicy   string `json:"PricePolicy"`
+}
+
+// 資産マスタの部分更新（指定された項目のみ変更する）
+type AssetMasterPatchReq struct {
+	AssetCode     string  `json:"AssetCode"`
+	CategoryId    *string `json:"CategoryId"`
+	Name          *string `json:"Name"`
+	Type          *int    `json:"Type"`
+	PriceProvider *string `json:"PriceProvider"`
+	Region        *string `json:"Region"`
+	Currency      *string `json:"Currency"`
+	NavDateOffset *int    `json:"NavDateOffset"`
+	PricePolicy   *string `json:"PricePolicy"`
 }
 
 /*
  * 指定した資産コードまたはカテゴリーIDを元に資産マスタデータを取得
+ * カテゴリーIDのみの指定はGSIで取得する（どちらも指定がない場合は全件）
  */
 func GetAssetMasterByAssetCodeAndCategoryId(assetCode string, categoryId string) ([]AssetMaster, error) {
-	var assetMasterData []AssetMaster
-	// Dynamodb接続
-	table := connectDynamodb("asset_master")
-
-	filter := table.Get("AssetCode", assetCode)
-	if categoryId != "" {
-		filter = filter.Filter("'CategoryId' = ?", categoryId)
+	switch {
+	case assetCode != "" && categoryId != "":
+		return repository.AssetMaster.FindByAssetCodeAndCategoryId(assetCode, categoryId)
+	case assetCode != "":
+		return repository.AssetMaster.FindByAssetCode(assetCode)
+	case categoryId != "":
+		return repository.AssetMaster.FindByCategoryId(categoryId)
 	}
-	err := filter.All(&assetMasterData)
+	return repository.AssetMaster.FindAll()
+}
 
-	return assetMasterData, err
+/*
+ * 全ての資産マスタデータを取得
+ */
+func GetAssetMasterList() ([]AssetMaster, error) {
+	return repository.AssetMaster.FindAll()
 }
 
 // 指定したコードの資産名取得
 func GetAssetName(assetCode string) (string, error) {
-	var assetMasterData []AssetMaster
-	// Dynamodb接続
-	table := connectDynamodb("asset_master")
-	err := table.Get("AssetCode", assetCode).All(&assetMasterData)
-	name := assetMasterData[0].Name
-
-	return name, err
+	assetMasterData, err := repository.AssetMaster.FindByAssetCode(assetCode)
+	if err != nil || len(assetMasterData) == 0 {
+		return "", err
+	}
+	return assetMasterData[0].Name, nil
 }
 
 /*
  * 資産マスターデータを保存
  */
 func SaveAssetMaster(assetMasterReq *AssetMasterReq) error {
-	// Dynamodb接続
-	table := connectDynamodb("asset_master")
+	assetMasterData, err := newAssetMaster(assetMasterReq)
+	if err != nil {
+		return err
+	}
+	return repository.AssetMaster.Save(assetMasterData)
+}
+
+/*
+ * 登録済みの資産マスターデータを置き換える（資産コードで特定する）
+ * 全項目を置き換えるため、省略した項目（通貨・約定日オフセット・価格の決め方など）は既定値に戻る
+ * 一部の項目のみ変更する場合は PatchAssetMaster を使用する
+ * カテゴリーIDを変更した場合は、変更前のデータの削除と登録をまとめて行う
+ */
+func UpdateAssetMaster(assetMasterReq *AssetMasterReq) (AssetMaster, error) {
+	current, err := findAssetMaster(assetMasterReq.AssetCode)
+	if err != nil {
+		return AssetMaster{}, err
+	}
+	assetMasterData, err := newAssetMaster(assetMasterReq)
+	if err != nil {
+		return assetMasterData, err
+	}
+	err = repository.AssetMaster.Replace(current, assetMasterData)
+	return assetMasterData, err
+}
 
-	assetMasterData := AssetMaster{AssetCode: assetMasterReq.AssetCode, CategoryId: assetMasterReq.CategoryId,
-		Type: assetMasterReq.Type, Name: assetMasterReq.Name}
-	err := table.Put(assetMasterData).Run()
+/*
+ * 登録済みの資産マスターデータのうち、指定された項目のみを変更する
+ */
+func PatchAssetMaster(assetMasterPatchReq *AssetMasterPatchReq) (AssetMaster, error) {
+	current, err := findAssetMaster(assetMasterPatchReq.AssetCode)
+	if err != nil {
+		return AssetMaster{}, err
+	}
+	assetMasterReq := AssetMasterReq{AssetCode: current.AssetCode, CategoryId: current.CategoryId, Name: current.Name,
+		Type: current.Type, PriceProvider: current.PriceProvider, Region: current.Region, Currency: current.Currency,
+		NavDateOffset: current.NavDateOffset, PricePolicy: current.PricePolicy}
+	if assetMasterPatchReq.CategoryId != nil {
+		assetMasterReq.CategoryId = *assetMasterPatchReq.CategoryId
+	}
+	if assetMasterPatchReq.Name != nil {
+		assetMasterReq.Name = *assetMasterPatchReq.Name
+	}
+	if assetMasterPatchReq.Type != nil {
+		assetMasterReq.Type = *assetMasterPatchReq.Type
+	}
+	if assetMasterPatchReq.PriceProvider != nil {
+		assetMasterReq.PriceProvider = *assetMasterPatchReq.PriceProvider
+	}
+	if assetMasterPatchReq.Region != nil {
+		assetMasterReq.Region = *assetMasterPatchReq.Region
+	}
+	if assetMasterPatchReq.Currency != nil {
+		assetMasterReq.Currency = *assetMasterPatchReq.Currency
+	}
+	if assetMasterPatchReq.NavDateOffset != nil {
+		assetMasterReq.NavDateOffset = *assetMasterPatchReq.NavDateOffset
+	}
+	if assetMasterPatchReq.PricePolicy != nil {
+		assetMasterReq.PricePolicy = *assetMasterPatchReq.PricePolicy
+	}
+	return UpdateAssetMaster(&assetMasterReq)
+}
+
+/*
+ * 資産マスターデータを削除
+ * 取引・価格・未約定の注文・配当金等が登録されている資産は削除できない
+ */
+func DeleteAssetMaster(assetCode string) error {
+	current, err := findAssetMaster(assetCode)
+	if err != nil {
+		return err
+	}
+
+	assetBuyList, err := repository.AssetBuy.FindByAssetCode(assetCode)
 	if err != nil {
 		return err
 	}
-	return nil
+	if len(assetBuyList) > 0 {
+		return newValidationError("asset has transactions: " + assetCode)
+	}
+	latestDate, err := repository.AssetPrice.FindLatestDate(assetCode)
+	if err != nil {
+		return err
+	}
+	if latestDate != "" {
+		return newValidationError("asset has prices: " + assetCode)
+	}
+	assetOrderList, err := repository.AssetOrder.FindByAssetCode(assetCode)
+	if err != nil {
+		return err
+	}
+	if len(assetOrderList) > 0 {
+		return newValidationError("asset has pending orders: " + assetCode)
+	}
+	assetDistributionList, err := repository.AssetDistribution.FindByAssetCode(assetCode)
+	if err != nil {
+		return err
+	}
+	if len(assetDistributionList) > 0 {
+		return newValidationError("asset has distributions: " + assetCode)
+	}
+	return repository.AssetMaster.Delete(current.AssetCode, current.CategoryId)
+}
+
+// 資産コードに一致する登録済みの資産マスタを取得（未登録の場合は入力誤りとする）
+func findAssetMaster(assetCode string) (AssetMaster, error) {
+	if assetCode == "" {
+		return AssetMaster{}, newValidationError("asset code is required")
+	}
+	assetMaster, err := GetAssetMasterByAssetCodeAndCategoryId(assetCode, "")
+	if err != nil {
+		return AssetMaster{}, err
+	}
+	if len(assetMaster) == 0 {
+		return AssetMaster{}, newValidationError("asset master not found: " + assetCode)
+	}
+	return assetMaster[0], nil
+}
+
+// 入力内容を確認して資産マスタを生成
+func newAssetMaster(assetMasterReq *AssetMasterReq) (AssetMaster, error) {
+	if assetMasterReq.AssetCode == "" {
+		return AssetMaster{}, newValidationError("asset code is required")
+	}
+	// 価格取得元が指定されている場合は、登録済みの取得元か確認する
+	if assetMasterReq.PriceProvider != "" {
+		nameList := GetPriceProviderNameList()
+		idx := sort.SearchStrings(nameList, assetMasterReq.PriceProvider)
+		if idx == len(nameList) || nameList[idx] != assetMasterReq.PriceProvider {
+			return AssetMaster{}, newValidationError("unknown price provider: " + assetMasterReq.PriceProvider +
+				" (available: " + strings.Join(nameList, ", ") + ")")
+		}
+	}
+
+	if assetMasterReq.NavDateOffset < 0 {
+		return AssetMaster{}, newValidationError("nav date offset must not be negative")
+	}
+	switch assetMasterReq.PricePolicy {
+	case "", config.PRICE_POLICY_PRIOR, config.PRICE_POLICY_NEXT:
+	default:
+		return AssetMaster{}, newValidationError("invalid price policy: " + assetMasterReq.PricePolicy)
+	}
+
+	// 指数はカテゴリーに属さないため、カテゴリーIDの指定がなければ指数用のIDを設定する
+	categoryId := assetMasterReq.CategoryId
+	if assetMasterReq.Type == config.ASSET_TYPE_INDEX && categoryId == "" {
+		categoryId = config.INDEX_CATEGORY_ID
+	}
+
+	return AssetMaster{AssetCode: assetMasterReq.AssetCode, CategoryId: categoryId,
+		Type: assetMasterReq.Type, Name: assetMasterReq.Name, PriceProvider: assetMasterReq.PriceProvider,
+		Region: assetMasterReq.Region, Currency: assetMasterReq.Currency, NavDateOffset: assetMasterReq.NavDateOffset,
+		PricePolicy: assetMasterReq.PricePolicy}, nil
+}
+
+// 資産マスタの通貨を更新
+func updateAssetMasterCurrency(assetMaster AssetMaster, currency string) error {
+	// 為替レートなど資産マスタに登録されていない資産は対象外
+	if assetMaster.CategoryId == "" {
+		return nil
+	}
+	return repository.AssetMaster.UpdateCurrency(assetMaster, currency)
 }
@@ -0,0 +1,182 @@
+package models
+
+import (
+	"code/config"
+	"encoding/base64"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// 資産マスタの検索条件（クエリパラメータの文字列をそのまま受け取る。空文字は指定なし）
+type AssetMasterQuery struct {
+	CategoryId string
+	// 資産タイプ
+	Type string
+	// 資産名の部分一致（全角・半角、カタカナ・ひらがな、大文字・小文字、旧字体を区別しない）
+	Name string
+	// 1ページあたりの件数
+	Limit string
+	// 前のページの NextCursor（先頭ページは空文字）
+	Cursor string
+}
+
+// 資産マスタの検索結果（資産コード順）
+type AssetMasterPage struct {
+	Items []AssetMaster
+	// 条件に一致した全件数
+	Total int
+	// 次のページを取得する際の Cursor（最終ページの場合は空文字）
+	NextCursor string `json:",omitempty"`
+}
+
+// カーソルの資産コードとカテゴリーIDの区切り（カテゴリーIDは "#" を含まない）
+const assetMasterCursorSeparator = "#"
+
+// 半角カタカナ（U+FF61〜U+FF9D）に対応する全角文字
+const halfwidthKatakanaList = "。「」、・ヲァィゥェォャュョッーアイウエオカキクケコサシスセソタチツテトナニヌネノハヒフヘホマミムメモヤユヨラリルレロワン"
+
+// 濁点・半濁点を付けられるカタカナ
+const dakutenKatakanaList = "カキクケコサシスセソタチツテトハヒフヘホ"
+const handakutenKatakanaList = "ハヒフヘホ"
+
+// 検索時に同一視する異体字・記号
+var searchVariantReplacer = strings.NewReplacer(
+	"髙", "高", "﨑", "崎", "嶋", "島", "濵", "浜", "邊", "辺", "邉", "辺", "齋", "斎", "齊", "斉",
+	"國", "国", "澤", "沢", "櫻", "桜", "廣", "広", "與", "与", "會", "会", "來", "来", "證", "証",
+	"‐", "-", "‑", "-", "–", "-", "—", "-", "―", "-", "−", "-",
+)
+
+/*
+ * 資産マスタを検索条件で絞り込み、資産コード順に1ページ分を取得
+ * カテゴリーIDの指定はGSIで取得し、資産タイプと資産名はその結果から絞り込む
+ * 資産名の正規化はDynamodbの条件式では行えないため、対象の資産マスタを全件取得してから絞り込む
+ * （資産マスタは保有・監視する銘柄のみを登録するため、全件取得できる件数に収まる前提）
+ */
+func SearchAssetMaster(query AssetMasterQuery) (AssetMasterPage, error) {
+	var page AssetMasterPage
+	assetType := 0
+	if query.Type != "" {
+		var err error
+		if assetType, err = strconv.Atoi(query.Type); err != nil {
+			return page, newValidationError("invalid type: " + query.Type)
+		}
+	}
+	limit := config.ASSET_MASTER_PAGE_LIMIT_DEFAULT
+	if query.Limit != "" {
+		var err error
+		if limit, err = strconv.Atoi(query.Limit); err != nil || limit <= 0 || limit > config.ASSET_MASTER_PAGE_LIMIT_MAX {
+			return page, newValidationError("limit must be between 1 and " + strconv.Itoa(config.ASSET_MASTER_PAGE_LIMIT_MAX))
+		}
+	}
+	cursorAssetCode, cursorCategoryId, err := decodeAssetMasterCursor(query.Cursor)
+	if err != nil {
+		return page, err
+	}
+
+	assetMasterList, err := GetAssetMasterByAssetCodeAndCategoryId("", query.CategoryId)
+	if err != nil {
+		return page, err
+	}
+	name := normalizeSearchText(query.Name)
+	matchedList := []AssetMaster{}
+	for _, assetMaster := range assetMasterList {
+		if query.Type != "" && assetMaster.Type != assetType {
+			continue
+		}
+		if name != "" && !strings.Contains(normalizeSearchText(assetMaster.Name), name) {
+			continue
+		}
+		matchedList = append(matchedList, assetMaster)
+	}
+	sort.Slice(matchedList, func(i, j int) bool {
+		return assetMasterKeyLess(matchedList[i].AssetCode, matchedList[i].CategoryId, matchedList[j].AssetCode, matchedList[j].CategoryId)
+	})
+	page.Total = len(matchedList)
+
+	// 前のページの最後の資産マスタ（資産コードとカテゴリーID）より後から取得する
+	start := 0
+	if query.Cursor != "" {
+		start = sort.Search(len(matchedList), func(i int) bool {
+			return assetMasterKeyLess(cursorAssetCode, cursorCategoryId, matchedList[i].AssetCode, matchedList[i].CategoryId)
+		})
+	}
+	end := start + limit
+	if end < len(matchedList) {
+		page.NextCursor = encodeAssetMasterCursor(matchedList[end-1])
+	} else {
+		end = len(matchedList)
+	}
+	page.Items = matchedList[start:end]
+	return page, nil
+}
+
+// 資産コード、カテゴリーIDの順に比較
+func assetMasterKeyLess(assetCode1 string, categoryId1 string, assetCode2 string, categoryId2 string) bool {
+	if assetCode1 != assetCode2 {
+		return assetCode1 < assetCode2
+	}
+	return categoryId1 < categoryId2
+}
+
+// 資産マスタの資産コードとカテゴリーIDからカーソルを生成
+func encodeAssetMasterCursor(assetMaster AssetMaster) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(assetMaster.AssetCode + assetMasterCursorSeparator + assetMaster.CategoryId))
+}
+
+// カーソルから資産コードとカテゴリーIDを取得（空文字の場合は先頭ページ）
+func decodeAssetMasterCursor(cursor string) (string, string, error) {
+	if cursor == "" {
+		return "", "", nil
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	idx := strings.LastIndex(string(decoded), assetMasterCursorSeparator)
+	if err != nil || idx < 0 {
+		return "", "", newValidationError("invalid cursor: " + cursor)
+	}
+	return string(decoded[:idx]), string(decoded[idx+1:]), nil
+}
+
+// 検索用に文字列を正規化
+// 全角英数記号を半角に、半角カタカナを全角に、カタカナをひらがなに、英字を小文字にし、異体字を揃えて空白・中黒を除く
+func normalizeSearchText(text string) string {
+	var runeList []rune
+	for _, r := range searchVariantReplacer.Replace(text) {
+		switch {
+		case unicode.IsSpace(r):
+			continue
+		case r >= '！' && r <= '～':
+			// 全角英数記号
+			r = r - 0xFEE0
+		case r >= '｡' && r <= 'ﾝ':
+			r = []rune(halfwidthKatakanaList)[r-'｡']
+		case r == 'ﾞ' || r == '゙' || r == 'ﾟ' || r == '゚':
+			// 濁点・半濁点は直前のカタカナと合成する（合成できない場合は除く）
+			if len(runeList) > 0 {
+				last := &runeList[len(runeList)-1]
+				switch {
+				case (r == 'ﾞ' || r == '゙') && *last == 'ウ':
+					*last = 'ヴ'
+				case (r == 'ﾞ' || r == '゙') && strings.ContainsRune(dakutenKatakanaList, *last):
+					*last = *last + 1
+				case (r == 'ﾟ' || r == '゚') && strings.ContainsRune(handakutenKatakanaList, *last):
+					*last = *last + 2
+				}
+			}
+			continue
+		}
+		// 中黒は空白と同じく区切りとして除く
+		if r == '・' {
+			continue
+		}
+		runeList = append(runeList, unicode.ToLower(r))
+	}
+	// カタカナをひらがなに揃える（濁点の合成後に変換する）
+	for idx, r := range runeList {
+		if r >= 'ァ' && r <= 'ヶ' {
+			runeList[idx] = r - 0x60
+		}
+	}
+	return string(runeList)
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"code/config"
+	"errors"
+	"testing"
+)
+
+// 同じ資産コードが複数のカテゴリーに登録されていても、ページの境界で読み飛ばさない
+func TestSearchAssetMasterCursor(t *testing.T) {
+	defer SetRepository(SetRepository(NewMemoryRepository()))
+	for _, assetMaster := range []AssetMaster{
+		{AssetCode: "A", CategoryId: "1", Name: "A", Type: config.ASSET_TYPE_STOCK},
+		{AssetCode: "B", CategoryId: "1", Name: "B", Type: config.ASSET_TYPE_STOCK},
+		{AssetCode: "B", CategoryId: "2", Name: "B", Type: config.ASSET_TYPE_STOCK},
+		{AssetCode: "C", CategoryId: "2", Name: "C", Type: config.ASSET_TYPE_STOCK},
+	} {
+		if err := repository.AssetMaster.Save(assetMaster); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var keyList []string
+	cursor := ""
+	for pageCount := 0; pageCount < 10; pageCount++ {
+		page, err := SearchAssetMaster(AssetMasterQuery{Limit: "2", Cursor: cursor})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Total != 4 {
+			t.Errorf("Total = %d, want 4", page.Total)
+		}
+		for _, assetMaster := range page.Items {
+			keyList = append(keyList, assetMaster.AssetCode+"/"+assetMaster.CategoryId)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	want := []string{"A/1", "B/1", "B/2", "C/2"}
+	if len(keyList) != len(want) {
+		t.Fatalf("items = %v, want %v", keyList, want)
+	}
+	for idx := range want {
+		if keyList[idx] != want[idx] {
+			t.Errorf("items = %v, want %v", keyList, want)
+			break
+		}
+	}
+
+	var validationError *ValidationError
+	for _, cursor := range []string{"!!", "QQ"} {
+		if _, err := SearchAssetMaster(AssetMasterQuery{Cursor: cursor}); !errors.As(err, &validationError) {
+			t.Errorf("cursor %q: error = %v, want ValidationError", cursor, err)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"code/config"
+	"testing"
+)
+
+// カテゴリーIDを変更しても資産マスタは1件のまま、指定しなかった項目は PATCH では変わらない
+func TestPatchAssetMasterCategory(t *testing.T) {
+	defer SetRepository(SetRepository(NewMemoryRepository()))
+	saveTestAssetMasterAndPrice(t)
+
+	categoryId := "3"
+	assetMaster, err := PatchAssetMaster(&AssetMasterPatchReq{AssetCode: "FUND", CategoryId: &categoryId})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assetMaster.CategoryId != "3" || assetMaster.NavDateOffset != 1 || assetMaster.PriceProvider != config.PRICE_PROVIDER_SBI {
+		t.Errorf("PatchAssetMaster = %+v, want category 3 with other fields kept", assetMaster)
+	}
+	assetMasterList, err := GetAssetMasterByAssetCodeAndCategoryId("FUND", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(assetMasterList) != 1 || assetMasterList[0].CategoryId != "3" {
+		t.Errorf("asset master = %+v, want category 3 only", assetMasterList)
+	}
+
+	// PUT は全項目の置き換えのため、省略した項目は既定値に戻る
+	assetMaster, err = UpdateAssetMaster(&AssetMasterReq{AssetCode: "FUND", CategoryId: "3", Name: "先進国株式ファンド",
+		Type: config.ASSET_TYPE_INVESTMENT_TRUST, PriceProvider: config.PRICE_PROVIDER_SBI})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assetMaster.NavDateOffset != 0 || assetMaster.Currency != "" {
+		t.Errorf("UpdateAssetMaster = %+v, want default nav date offset and currency", assetMaster)
+	}
+}
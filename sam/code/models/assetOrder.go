@@ -0,0 +1,177 @@
+package models
+
+import (
+	"code/config"
+	"code/decimal"
+	"sort"
+	"time"
+)
+
+// 未約定の注文（約定日の価格の公表待ち）
+type AssetOrder struct {
+	AssetCode     string
+	TransactionId string
+	OrderDate     string
+	TradeType     int
+	Unit          decimal.Decimal
+	Amount        decimal.Decimal
+	PlanId        string
+}
+
+// 注文毎の約定結果
+type OrderSettlementResult struct {
+	AssetCode     string
+	TransactionId string
+	OrderDate     string
+	// 約定日（未約定の場合は空文字）
+	ExecutionDate string
+	// 約定に使用した価格の日付
+	PriceDate string
+	Error     string
+}
+
+// 未約定の注文の約定処理の実行結果
+type OrderSettlementSummary struct {
+	StartedAt    string
+	FinishedAt   string
+	SettledCount int
+	PendingCount int
+	FailureCount int
+	Results      []OrderSettlementResult
+}
+
+/*
+ * 未約定の注文の一覧を取得（資産コードの指定がない場合は全件）
+ */
+func GetAssetOrderList(assetCode string) ([]AssetOrder, error) {
+	return repository.AssetOrder.FindByAssetCode(assetCode)
+}
+
+/*
+ * 未約定の注文のうち、約定日の価格が公表されたものを約定して取引データに登録
+ * 約定した注文は未約定の注文から削除する
+ */
+func SettleAllAssetOrder() (OrderSettlementSummary, error) {
+	summary := OrderSettlementSummary{StartedAt: time.Now().Format(time.RFC3339)}
+	assetOrderList, err := GetAssetOrderList("")
+	if err != nil {
+		return summary, err
+	}
+	// 注文日順に約定する（売却の保有口数の確認を購入の後にするため）
+	sort.Slice(assetOrderList, func(i, j int) bool {
+		return assetOrderList[i].TransactionId < assetOrderList[j].TransactionId
+	})
+
+	assetMasterByAssetCode := make(map[string]AssetMaster)
+	for _, assetOrder := range assetOrderList {
+		result := OrderSettlementResult{AssetCode: assetOrder.AssetCode, TransactionId: assetOrder.TransactionId,
+			OrderDate: assetOrder.OrderDate}
+
+		assetMaster, ok := assetMasterByAssetCode[assetOrder.AssetCode]
+		if !ok {
+			assetMasterList, err := GetAssetMasterByAssetCodeAndCategoryId(assetOrder.AssetCode, "")
+			if err != nil || len(assetMasterList) == 0 {
+				result.Error = "asset master not found: " + assetOrder.AssetCode
+				summary.FailureCount++
+				summary.Results = append(summary.Results, result)
+				continue
+			}
+			assetMaster = assetMasterList[0]
+			assetMasterByAssetCode[assetOrder.AssetCode] = assetMaster
+		}
+
+		execution, ok, err := resolveOrderExecution(assetMaster, assetOrder.OrderDate)
+		switch {
+		case err != nil:
+			result.Error = err.Error()
+		case !ok:
+			summary.PendingCount++
+			continue
+		default:
+			result.ExecutionDate = execution.date
+			result.PriceDate = execution.priceDate
+			if _, err := executeAssetOrder(assetOrder, assetMaster, execution, false); err != nil {
+				result.Error = err.Error()
+			} else if err := repository.AssetOrder.Delete(assetOrder.AssetCode, assetOrder.TransactionId); err != nil {
+				result.Error = err.Error()
+			}
+		}
+		if result.Error != "" {
+			summary.FailureCount++
+		} else {
+			summary.SettledCount++
+		}
+		summary.Results = append(summary.Results, result)
+	}
+	summary.FinishedAt = time.Now().Format(time.RFC3339)
+	return summary, nil
+}
+
+// 未約定の注文を保存
+func saveAssetOrder(assetOrder AssetOrder) error {
+	return repository.AssetOrder.Save(assetOrder)
+}
+
+// 約定日と約定に使用する価格
+type orderExecution struct {
+	date      string
+	priceDate string
+	price     decimal.Decimal
+}
+
+// 注文日と資産マスタの約定日オフセット・価格の決め方から約定日と価格を決める（価格が未公表の場合は false）
+// 約定日オフセットがある場合は、価格のある日を営業日とし、注文日（休場日の場合は翌営業日）からオフセット分の営業日後を約定日とする
+// オフセットがない場合は注文日を約定日とし、注文日に価格がなければ直前または直後の価格を使用する
+func resolveOrderExecution(assetMaster AssetMaster, orderDate string) (orderExecution, bool, error) {
+	execution := orderExecution{date: orderDate}
+	// 現金は価格を参照しない
+	if assetMaster.Type == config.ASSET_TYPE_CACHE {
+		execution.price = decimal.NewFromInt(1)
+		return execution, true, nil
+	}
+
+	priceList, err := GetAssetPriceByAssetCodeAndDate(assetMaster.AssetCode, "", "")
+	if err != nil {
+		return execution, false, err
+	}
+	if len(priceList) == 0 {
+		return execution, false, newValidationError("no price registered for asset: " + assetMaster.AssetCode)
+	}
+	sort.Slice(priceList, func(i, j int) bool {
+		return priceList[i].Date < priceList[j].Date
+	})
+
+	// 注文日以降で最初に価格のある日の位置
+	idx := sort.Search(len(priceList), func(i int) bool {
+		return priceList[i].Date >= orderDate
+	})
+
+	if assetMaster.NavDateOffset > 0 {
+		idx = idx + assetMaster.NavDateOffset
+		if idx >= len(priceList) {
+			return execution, false, nil
+		}
+		execution.date = priceList[idx].Date
+		execution.priceDate = priceList[idx].Date
+		execution.price = priceList[idx].Price
+		return execution, true, nil
+	}
+
+	switch {
+	case idx < len(priceList) && priceList[idx].Date == orderDate:
+		// 注文日の価格
+	case idx >= len(priceList):
+		// 最新の価格より後の注文日は、価格の公表を待つ
+		return execution, false, nil
+	case assetMaster.PricePolicy == config.PRICE_POLICY_NEXT:
+		// 直後の価格
+	case idx == 0:
+		return execution, false, newValidationError("no price on or before " + orderDate + " for asset: " + assetMaster.AssetCode)
+	default:
+		// 直前の価格
+		idx--
+	}
+	execution.priceDate = priceList[idx].Date
+	execution.price = priceList[idx].Price
+	return execution, true, nil
+}
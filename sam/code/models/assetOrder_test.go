@@ -0,0 +1,53 @@
+package models
+
+import (
+	"code/config"
+	"testing"
+)
+
+// 約定日の価格が未公表の注文は未約定の注文として保存し、価格の公表後に約定する
+func TestSettleAllAssetOrder(t *testing.T) {
+	defer SetRepository(SetRepository(NewMemoryRepository()))
+	saveTestAssetMasterAndPrice(t)
+
+	// FUND は注文日の翌営業日の基準価格で約定する
+	result := saveTestAssetBuy(t, AssetBuyReq{AssetCode: "FUND", Date: "2021-09-22", TradeType: config.TRADE_TYPE_BUY, Amount: mustDecimal(t, "10000")})
+	if result.AssetOrder == nil || result.AssetBuy != nil {
+		t.Fatalf("SaveAssetBuy = %+v, want pending order", result)
+	}
+
+	summary, err := SettleAllAssetOrder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.SettledCount != 0 || summary.PendingCount != 1 {
+		t.Errorf("before price: %+v, want 1 pending", summary)
+	}
+
+	if err := repository.AssetPrice.Save(AssetDaily{AssetCode: "FUND", Date: "2021-09-24", Price: mustDecimal(t, "10200")}); err != nil {
+		t.Fatal(err)
+	}
+	summary, err = SettleAllAssetOrder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.SettledCount != 1 || summary.PendingCount != 0 || summary.FailureCount != 0 {
+		t.Fatalf("after price: %+v, want 1 settled", summary)
+	}
+	if got := summary.Results[0]; got.ExecutionDate != "2021-09-24" || got.PriceDate != "2021-09-24" {
+		t.Errorf("result = %+v, want executed on 2021-09-24", got)
+	}
+
+	assetBuyList, err := GetAssetBuyByAssetCode("FUND")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 10000円 / 10200円 * 1万口 = 9803.92口 → 9804口、金額は口数から算出し直す
+	if len(assetBuyList) != 1 || assetBuyList[0].Date != "2021-09-24" || assetBuyList[0].OrderDate != "2021-09-22" ||
+		assetBuyList[0].Unit.String() != "9804" || assetBuyList[0].Amount.String() != "10000" {
+		t.Errorf("asset buy = %+v, want 9804 units on 2021-09-24", assetBuyList)
+	}
+	if assetOrderList, _ := GetAssetOrderList(""); len(assetOrderList) != 0 {
+		t.Errorf("asset order = %+v, want none", assetOrderList)
+	}
+}
@@ -1,293 +1,240 @@
 package models
 
 import (
-	"bytes"
-	"encoding/json"
-	"io/ioutil"
-	"net/http"
-	"net/url"
-	"os"
+	"code/config"
+	"code/decimal"
+	"errors"
+	"regexp"
 	"strconv"
-	"strings"
 	"time"
-
-	"github.com/PuerkitoBio/goquery"
-	"github.com/saintfish/chardet"
-	"golang.org/x/net/html/charset"
 )
 
+// 日付の形式(yyyy-mm-dd)
+const dateLayout = "2006-01-02"
+
 type AssetDaily struct {
 	AssetCode string
 	Date      string
-	Price     int
+	Price     decimal.Decimal
+}
+
+// 価格の保存件数
+type PriceSaveResult struct {
+	// 新規に登録した件数
+	Inserted int
+	// 登録済みの価格から変更した件数
+	Updated int
+	// 登録済みの価格と同じため書き込まなかった件数
+	Unchanged int
 }
 
 type AssetPriceReq struct {
-	AssetType string `json:"AssetType"`
 	AssetCode string `json:"AssetCode"`
 	FromDate  string `json:"FromDate"`
 	ToDate    string `json:"ToDate"`
-	Region    string `json:"Region"`
-	GetRange  string `json:"GetRange"`
+	// 以下は価格取得元を資産マスタで管理する前の形式のリクエストとの互換のため受け付ける
+	// 資産タイプ（stock: 株価, investmentTrust: 投資信託の基準価格）。資産マスタに価格取得元がない場合に取得元を判定する
+	AssetType string `json:"AssetType"`
+	// 対象地域（ex: US, JP）。資産マスタに未設定の場合に使用する
+	Region string `json:"Region"`
+	// 取得期間（ex: 1d, 5d, 1mo, 1y, ytd）。開始日の指定がない場合に、終了日までの期間として使用する
+	GetRange string `json:"GetRange"`
 }
 
-type YahooFinanceStockData struct {
-	Chart struct {
-		Result []struct {
-			Meta struct {
-				Currency             string  `json:"currency"`
-				Symbol               string  `json:"symbol"`
-				ExchangeName         string  `json:"exchangeName"`
-				InstrumentType       string  `json:"instrumentType"`
-				FirstTradeDate       int     `json:"firstTradeDate"`
-				RegularMarketTime    int     `json:"regularMarketTime"`
-				Gmtoffset            int     `json:"gmtoffset"`
-				Timezone             string  `json:"timezone"`
-				ExchangeTimezoneName string  `json:"exchangeTimezoneName"`
-				RegularMarketPrice   float64 `json:"regularMarketPrice"`
-				ChartPreviousClose   float64 `json:"chartPreviousClose"`
-				PriceHint            int     `json:"priceHint"`
-				CurrentTradingPeriod struct {
-					Pre struct {
-						Timezone  string `json:"timezone"`
-						Start     int    `json:"start"`
-						End       int    `json:"end"`
-						Gmtoffset int    `json:"gmtoffset"`
-					} `json:"pre"`
-					Regular struct {
-						Timezone  string `json:"timezone"`
-						Start     int    `json:"start"`
-						End       int    `json:"end"`
-						Gmtoffset int    `json:"gmtoffset"`
-					} `json:"regular"`
-					Post struct {
-						Timezone  string `json:"timezone"`
-						Start     int    `json:"start"`
-						End       int    `json:"end"`
-						Gmtoffset int    `json:"gmtoffset"`
-					} `json:"post"`
-				} `json:"currentTradingPeriod"`
-				DataGranularity string   `json:"dataGranularity"`
-				Range           string   `json:"range"`
-				ValidRanges     []string `json:"validRanges"`
-			} `json:"meta"`
-			Timestamp  []int `json:"timestamp"`
-			Indicators struct {
-				Quote []struct {
-					Low    []float64 `json:"low"`
-					Close  []float64 `json:"close"`
-					Volume []float64 `json:"volume"`
-					Open   []float64 `json:"open"`
-					High   []float64 `json:"high"`
-				} `json:"quote"`
-				Adjclose []struct {
-					Adjclose []float64 `json:"adjclose"`
-				} `json:"adjclose"`
-			} `json:"indicators"`
-		} `json:"result"`
-		Error error `json:"error"`
-	} `json:"chart"`
+// 旧形式のリクエストの資産タイプに対応する価格取得元
+var legacyAssetTypePriceProvider = map[string]string{
+	"stock":           config.PRICE_PROVIDER_YAHOO_FINANCE,
+	"investmentTrust": config.PRICE_PROVIDER_SBI,
 }
 
+// 取得期間の形式（ex: 5d, 2wk, 3mo, 1y）
+var priceRangePattern = regexp.MustCompile(`^([0-9]+)(d|wk|mo|y)$`)
+
 /*
  * 指定した資産コードまたは日付を元に資産価格データを取得
+ * fromDate, toDate(yyyy-mm-dd) で期間を指定する（片方のみの指定も可）
  */
 func GetAssetPriceByAssetCodeAndDate(assetCode string, fromDate string, toDate string) ([]AssetDaily, error) {
-	var assetDailyData []AssetDaily
-	// Dynamodb接続
-	table := connectDynamodb("asset_daily")
-
 	if assetCode == "" {
 		return nil, nil
 	}
-	filter := table.Get("AssetCode", assetCode)
-	if fromDate != "" && toDate != "" {
-		filter = filter.Filter("'Date' >= ?", fromDate)
-		filter = filter.Filter("'Date' <= ?", toDate)
-	}
-	err := filter.All(&assetDailyData)
-
-	return assetDailyData, err
+	return repository.AssetPrice.FindByAssetCode(assetCode, fromDate, toDate)
 }
 
 /*
- * 最新の日付を取得
+ * 指定した日付の全資産の価格データを取得
  */
-func GetLatestDay(assetCode string) string {
-	var assetDailyData []AssetDaily
-	// Dynamodb接続
-	table := connectDynamodb("asset_daily")
-	table.Get("AssetCode", assetCode).Order(false).Limit(1).All(&assetDailyData)
-	latestDay := assetDailyData[len(assetDailyData)-1].Date
-
-	return latestDay
+func GetAssetPriceByDate(date string) ([]AssetDaily, error) {
+	return repository.AssetPrice.FindByDate(date)
 }
 
 /*
- * 投資信託の基準価格時系列データを保存（SBIからスクレイピングで取得）
+ * 最新の日付を取得（価格データがない場合は空文字）
  */
-func SavePriceInvestmentTrust(assetCode string, fromDate string, toDate string) error {
-	var assetDailyData AssetDaily
-	// 日付設定
-	splitfromDate := strings.Split(fromDate, "-")
-	splitToDate := strings.Split(toDate, "-")
-	fromYear := splitfromDate[0]
-	fromMonth := splitfromDate[1]
-	fromDay := splitfromDate[2]
-	toYear := splitToDate[0]
-	toMonth := splitToDate[1]
-	toDay := splitToDate[2]
+func GetLatestDay(assetCode string) (string, error) {
+	return repository.AssetPrice.FindLatestDate(assetCode)
+}
 
-	// Post用のパラメータ設定
-	values := url.Values{}
-	values.Set("in_term_from_yyyy", fromYear)
-	values.Set("in_term_from_mm", fromMonth)
-	values.Set("in_term_from_dd", fromDay)
-	values.Set("in_term_to_yyyy", toYear)
-	values.Set("in_term_to_mm", toMonth)
-	values.Set("in_term_to_dd", toDay)
-	values.Set("dispRows", "100")
-	// Dynamodb接続
-	table := connectDynamodb("asset_daily")
-	// データ1年毎に取得するため、ループは３回まで
-	for page := 0; page < 3; page++ {
-		values.Set("page", strconv.Itoa(page))
-		// 基準価格取得
-		dateList, priceList, _ := GetListPriceInvestmentTrust(assetCode, values)
-		// 不要な接続を防ぐため、ループを抜ける
-		if len(dateList) == 0 {
-			break
+/*
+ * 資産マスタに設定された価格取得元から、指定期間の価格データを取得して保存
+ * 終了日の指定がない場合は today までとし、開始日の指定がない場合は取得期間(GetRange)から算出する
+ * 資産マスタに未登録の資産は、資産タイプ(AssetType)から判定した取得元の価格のみを保存する（通貨は記録しない）
+ */
+func SaveAssetPrice(assetPriceReq *AssetPriceReq, today string) (PriceSaveResult, error) {
+	var result PriceSaveResult
+	if assetPriceReq.AssetCode == "" {
+		return result, newValidationError("asset code is required")
+	}
+	toDate := assetPriceReq.ToDate
+	if toDate == "" {
+		toDate = today
+	}
+	fromDate := assetPriceReq.FromDate
+	if fromDate == "" && assetPriceReq.GetRange != "" {
+		var err error
+		if fromDate, err = priceRangeFromDate(assetPriceReq.GetRange, toDate); err != nil {
+			return result, err
 		}
-		for idx, date := range dateList {
-			price, _ := strconv.Atoi(priceList[idx])
-			assetDailyData = AssetDaily{AssetCode: assetCode, Date: date, Price: price}
-
-			// 資産価値データ登録
-			err := table.Put(assetDailyData).Run()
-			if err != nil {
-				return err
-			}
+	}
+	for _, date := range []string{fromDate, toDate} {
+		if _, err := time.Parse(dateLayout, date); err != nil {
+			return result, newValidationError("invalid date: " + date)
 		}
-		// 間隔を開けて取得する
-		time.Sleep(time.Second * 5)
 	}
-	return nil
-}
-
-// sbiのHPに接続し、基準価格をスクレイピングで取得
-func GetListPriceInvestmentTrust(assetCode string, params url.Values) ([]string, []string, error) {
-	var valueList []string
-	var dateList []string
-
-	client := http.Client{}
-	url := "https://site0.sbisec.co.jp/marble/fund/history/standardprice.do?fund_sec_code=" + assetCode
+	if fromDate > toDate {
+		return result, newValidationError("from date is after to date")
+	}
 
-	// リクエスト発行
-	req, err := http.NewRequest("POST", url, strings.NewReader(params.Encode()))
+	// 資産マスタから価格取得元を取得
+	assetMasterList, err := GetAssetMasterByAssetCodeAndCategoryId(assetPriceReq.AssetCode, "")
 	if err != nil {
-		return nil, nil, err
+		return result, err
+	}
+	var assetMaster AssetMaster
+	if len(assetMasterList) > 0 {
+		assetMaster = assetMasterList[0]
+	} else if _, ok := legacyAssetTypePriceProvider[assetPriceReq.AssetType]; ok {
+		assetMaster = AssetMaster{AssetCode: assetPriceReq.AssetCode}
+	} else {
+		return result, newValidationError("asset master not found: " + assetPriceReq.AssetCode)
+	}
+	if assetMaster.PriceProvider == "" {
+		assetMaster.PriceProvider = legacyAssetTypePriceProvider[assetPriceReq.AssetType]
 	}
-	// ヘッダー追加
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Add("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_13_5)” \"AppleWebKit/537.36 (KHTML, like Gecko) Chrome")
+	if assetMaster.Region == "" {
+		assetMaster.Region = assetPriceReq.Region
+	}
+	return SaveAssetPriceByAssetMaster(assetMaster, fromDate, toDate)
+}
 
-	// リクエスト送信
-	resp, err := client.Do(req)
+// 取得期間（ex: 5d, 1mo, 1y, ytd）から、終了日までの期間の開始日を算出
+func priceRangeFromDate(priceRange string, toDate string) (string, error) {
+	to, err := time.Parse(dateLayout, toDate)
 	if err != nil {
-		return nil, nil, err
+		return "", newValidationError("invalid date: " + toDate)
 	}
-
-	defer resp.Body.Close()
-	body, _ := ioutil.ReadAll(resp.Body)
-
-	// 文字コード変換
-	det := chardet.NewTextDetector()
-	detRslt, _ := det.DetectBest(body)
-	bReader := bytes.NewReader(body)
-	reader, _ := charset.NewReaderLabel(detRslt.Charset, bReader)
-
-	// HTMLパース
-	htmlDoc, _ := goquery.NewDocumentFromReader(reader)
-
-	// 基準価格日取得
-	htmlDate := htmlDoc.Find("div.alC")
-	htmlDate.Each(func(index int, s *goquery.Selection) {
-		date := strings.Replace(s.Text(), "/", "-", -1)
-		dateList = append(dateList, date)
-	})
-	// 基準価格取得
-	htmlPrice := htmlDoc.Find("div.alR")
-	htmlPrice.Each(func(index int, s *goquery.Selection) {
-		if index%3 == 0 {
-			tmpPrice := strings.Replace(s.Text(), "円", "", -1)
-			price := strings.Replace(tmpPrice, ",", "", -1)
-			valueList = append(valueList, price)
-		}
-	})
-	return dateList, valueList, nil
+	if priceRange == "ytd" {
+		return time.Date(to.Year(), time.January, 1, 0, 0, 0, 0, time.UTC).Format(dateLayout), nil
+	}
+	match := priceRangePattern.FindStringSubmatch(priceRange)
+	if match == nil {
+		return "", newValidationError("invalid range: " + priceRange)
+	}
+	count, _ := strconv.Atoi(match[1])
+	var from time.Time
+	switch match[2] {
+	case "d":
+		from = to.AddDate(0, 0, -count)
+	case "wk":
+		from = to.AddDate(0, 0, -7*count)
+	case "mo":
+		from = to.AddDate(0, -count, 0)
+	case "y":
+		from = to.AddDate(-count, 0, 0)
+	}
+	return from.Format(dateLayout), nil
 }
 
 /*
- * 株価の時系列データを保存（Yahoo Finance APIから取得）
+ * 指定した資産の価格データを価格取得元から取得して保存
+ * 登録済みの価格と比較し、新規・変更のあった価格のみをまとめて書き込む
  */
-func SavePriceStock(region string, assetCode string, getRange string) error {
-	var assetDailyData AssetDaily
-	// Dynamodb接続
-	table := connectDynamodb("asset_daily")
-	// 基準価格取得
-	dateList, priceList, err := GetListPriceStock(region, assetCode, getRange)
+func SaveAssetPriceByAssetMaster(assetMaster AssetMaster, fromDate string, toDate string) (PriceSaveResult, error) {
+	var result PriceSaveResult
+	provider, err := GetPriceProviderByAssetMaster(assetMaster)
 	if err != nil {
-		return err
+		return result, err
 	}
 
-	for idx, date := range dateList {
-		price := priceList[idx]
-		assetDailyData = AssetDaily{AssetCode: assetCode, Date: date, Price: price}
-
-		// 資産価値データ登録
-		err := table.Put(assetDailyData).Run()
-		if err != nil {
-			return err
+	// 価格取得
+	fetchedPrice, err := provider.FetchPrice(assetMaster, fromDate, toDate)
+	if err != nil {
+		return result, err
+	}
+	// 取得元から通貨が判別できた場合は、資産マスタの通貨と照合する（未設定の場合は登録する）
+	if fetchedPrice.Currency != "" && fetchedPrice.Currency != assetMaster.Currency {
+		if assetMaster.Currency != "" {
+			return result, errors.New("currency mismatch: " + assetMaster.AssetCode + " is " + assetMaster.Currency +
+				" but price is " + fetchedPrice.Currency)
+		}
+		if err := updateAssetMasterCurrency(assetMaster, fetchedPrice.Currency); err != nil {
+			return result, err
 		}
 	}
-	return nil
+	return saveAssetDailyList(assetMaster.AssetCode, fetchedPrice.AssetDailyList)
 }
 
-// Yahoo Finance APIから指定したシンボルの株価を取得
-func GetListPriceStock(region string, assetCode string, getRange string) ([]string, []int, error) {
-	url := "https://apidojo-yahoo-finance-v1.p.rapidapi.com/stock/v2/get-chart?interval=1d&symbol=" + assetCode + "&range=" + getRange + "&region=" + region
-	req, _ := http.NewRequest("GET", url, nil)
-	req.Header.Add("x-rapidapi-key", os.Getenv("RAPIDAPI_Key"))
-	req.Header.Add("x-rapidapi-host", "apidojo-yahoo-finance-v1.p.rapidapi.com")
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, nil, err
+// 登録済みの価格と比較し、新規・変更のあった価格をまとめて書き込む
+func saveAssetDailyList(assetCode string, assetDailyList []AssetDaily) (PriceSaveResult, error) {
+	var result PriceSaveResult
+	if len(assetDailyList) == 0 {
+		return result, nil
 	}
 
-	defer res.Body.Close()
-	body, _ := ioutil.ReadAll(res.Body)
-	// JSONデコード
-	var yahooFinanceStockData YahooFinanceStockData
-	if err := json.Unmarshal(body, &yahooFinanceStockData); err != nil {
-		return nil, nil, err
+	// 取得した期間の登録済みの価格
+	fromDate, toDate := assetDailyList[0].Date, assetDailyList[0].Date
+	for _, data := range assetDailyList {
+		if data.Date < fromDate {
+			fromDate = data.Date
+		}
+		if data.Date > toDate {
+			toDate = data.Date
+		}
+	}
+	savedList, err := repository.AssetPrice.FindByAssetCode(assetCode, fromDate, toDate)
+	if err != nil {
+		return result, err
 	}
-	// APIエラー判定
-	if err := yahooFinanceStockData.Chart.Error; err != nil {
-		return nil, nil, err
+	savedPriceByDate := make(map[string]AssetDaily)
+	for _, data := range savedList {
+		savedPriceByDate[data.Date] = data
 	}
-	// 日付、価格取得
-	timestampList := yahooFinanceStockData.Chart.Result[0].Timestamp
-	adjcloseList := yahooFinanceStockData.Chart.Result[0].Indicators.Adjclose[0].Adjclose
-	const layout = "2006-01-02"
-	var dateList []string
-	var priceList []int
-	for idx, timestamp := range timestampList {
-		// Unixタイムスタンプデータをyyyy-mm-dd形式に変換
-		timeFull := time.Unix(int64(timestamp), 0)
-		dateList = append(dateList, timeFull.Format(layout))
-		// float64をintに変換
-		priceList = append(priceList, int(adjcloseList[idx]))
+
+	// 同じ日付の価格が重複している場合は後の価格を使う
+	writeIndexByDate := make(map[string]int)
+	var writeList []AssetDaily
+	for _, data := range assetDailyList {
+		saved, ok := savedPriceByDate[data.Date]
+		if ok && saved.Price.Equal(data.Price) {
+			result.Unchanged++
+			continue
+		}
+		if idx, ok := writeIndexByDate[data.Date]; ok {
+			writeList[idx] = data
+			continue
+		}
+		writeIndexByDate[data.Date] = len(writeList)
+		writeList = append(writeList, data)
 	}
 
-	return dateList, priceList, nil
+	// 新規・変更の件数は書き込みが完了した分だけ数える
+	wrote, err := repository.AssetPrice.SaveList(writeList)
+	for _, data := range writeList[:wrote] {
+		if _, ok := savedPriceByDate[data.Date]; ok {
+			result.Updated++
+		} else {
+			result.Inserted++
+		}
+	}
+	return result, err
 }
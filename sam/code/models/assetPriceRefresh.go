@@ -0,0 +1,114 @@
+package models
+
+import "time"
+
+// 価格データが未登録の資産を初回取得する日数
+const priceRefreshInitialDays = 365
+
+// 資産毎の価格更新結果
+type PriceRefreshResult struct {
+	AssetCode string
+	FromDate  string
+	ToDate    string
+	// 新規・変更・変更なしの件数
+	Inserted  int
+	Updated   int
+	Unchanged int
+	Error     string
+}
+
+// 価格更新の実行結果
+type PriceRefreshSummary struct {
+	StartedAt    string
+	FinishedAt   string
+	ToDate       string
+	SuccessCount int
+	FailureCount int
+	SkipCount    int
+	Results      []PriceRefreshResult
+}
+
+/*
+ * 資産マスタに登録された全資産の価格を、最新登録日の翌日から指定日まで取得して保存
+ * 基準通貨以外の通貨建ての資産がある場合は、その通貨の為替レートも取得する
+ * 1資産の取得に失敗しても他の資産の取得は継続し、結果を実行結果にまとめる
+ */
+func RefreshAllAssetPrice(toDate string) (PriceRefreshSummary, error) {
+	summary := PriceRefreshSummary{StartedAt: time.Now().Format(time.RFC3339), ToDate: toDate}
+
+	to, err := time.Parse(dateLayout, toDate)
+	if err != nil {
+		return summary, err
+	}
+	assetMasterList, err := GetAssetMasterList()
+	if err != nil {
+		return summary, err
+	}
+
+	// 為替レートの取得が必要な通貨を資産マスタの後に追加する
+	targetList := assetMasterList
+	currencyMap := map[string]bool{}
+	for _, assetMaster := range assetMasterList {
+		currency := AssetCurrency(assetMaster)
+		if currency != BaseCurrency() && !currencyMap[currency] {
+			currencyMap[currency] = true
+			targetList = append(targetList, FxAssetMaster(currency))
+		}
+	}
+
+	for _, assetMaster := range targetList {
+		result, skipped := refreshAssetPrice(assetMaster, to)
+		switch {
+		case skipped:
+			summary.SkipCount++
+			continue
+		case result.Error != "":
+			summary.FailureCount++
+		default:
+			summary.SuccessCount++
+		}
+		summary.Results = append(summary.Results, result)
+	}
+	summary.FinishedAt = time.Now().Format(time.RFC3339)
+	return summary, nil
+}
+
+// 1資産の価格を最新登録日の翌日から指定日まで取得して保存（取得対象外の場合は skipped を返す）
+func refreshAssetPrice(assetMaster AssetMaster, to time.Time) (result PriceRefreshResult, skipped bool) {
+	result = PriceRefreshResult{AssetCode: assetMaster.AssetCode, ToDate: to.Format(dateLayout)}
+
+	// 価格取得元がない資産（現金など）は対象外
+	if _, err := GetPriceProviderByAssetMaster(assetMaster); err != nil {
+		return result, true
+	}
+
+	// 最新登録日の翌日から取得する（未登録の場合は初回分を取得する）
+	latestDay, err := GetLatestDay(assetMaster.AssetCode)
+	if err != nil {
+		result.Error = err.Error()
+		return result, false
+	}
+	from := to.AddDate(0, 0, -priceRefreshInitialDays)
+	if latestDay != "" {
+		latest, err := time.Parse(dateLayout, latestDay)
+		if err != nil {
+			result.Error = err.Error()
+			return result, false
+		}
+		from = latest.AddDate(0, 0, 1)
+	}
+	// 取得済みの場合は対象外
+	if from.After(to) {
+		return result, true
+	}
+	result.FromDate = from.Format(dateLayout)
+
+	saveResult, err := SaveAssetPriceByAssetMaster(assetMaster, result.FromDate, result.ToDate)
+	result.Inserted = saveResult.Inserted
+	result.Updated = saveResult.Updated
+	result.Unchanged = saveResult.Unchanged
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result, false
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"code/config"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestPriceRangeFromDate(t *testing.T) {
+	cases := map[string]string{"1d": "2021-09-23", "5d": "2021-09-19", "2wk": "2021-09-10", "1mo": "2021-08-24",
+		"1y": "2020-09-24", "ytd": "2021-01-01"}
+	for priceRange, want := range cases {
+		if got, err := priceRangeFromDate(priceRange, "2021-09-24"); err != nil || got != want {
+			t.Errorf("priceRangeFromDate(%s) = %s, %v, want %s", priceRange, got, err, want)
+		}
+	}
+	var validationError *ValidationError
+	for _, priceRange := range []string{"max", "1", "d", "-1d"} {
+		if _, err := priceRangeFromDate(priceRange, "2021-09-24"); !errors.As(err, &validationError) {
+			t.Errorf("priceRangeFromDate(%s) error = %v, want ValidationError", priceRange, err)
+		}
+	}
+}
+
+// 旧形式のリクエスト（資産タイプ・地域・取得期間の指定）で、資産マスタ未登録の株価を保存できる
+func TestSaveAssetPriceLegacyRequest(t *testing.T) {
+	defer SetRepository(SetRepository(NewMemoryRepository()))
+	server := newYahooTestServer(t, http.StatusOK, "yahoo_chart.json")
+	defer server.Close()
+	previous, _ := GetPriceProvider(config.PRICE_PROVIDER_YAHOO_FINANCE)
+	RegisterPriceProvider(config.PRICE_PROVIDER_YAHOO_FINANCE, NewYahooFinancePriceProvider(server.Client(), server.URL, "test-key"))
+	defer RegisterPriceProvider(config.PRICE_PROVIDER_YAHOO_FINANCE, previous)
+
+	result, err := SaveAssetPrice(&AssetPriceReq{AssetType: "stock", AssetCode: "7203.T", Region: "JP", GetRange: "4d"}, "2021-09-24")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Inserted != 2 {
+		t.Errorf("Inserted = %d, want 2", result.Inserted)
+	}
+	// 資産マスタは登録しない
+	if assetMasterList, _ := GetAssetMasterList(); len(assetMasterList) != 0 {
+		t.Errorf("asset master = %v, want none", assetMasterList)
+	}
+
+	// 資産タイプの指定がなく資産マスタも未登録の場合は入力誤りとする
+	var validationError *ValidationError
+	if _, err := SaveAssetPrice(&AssetPriceReq{AssetCode: "7203.T", FromDate: "2021-09-20", ToDate: "2021-09-24"}, "2021-09-24"); !errors.As(err, &validationError) {
+		t.Errorf("error = %v, want ValidationError", err)
+	}
+	if _, err := SaveAssetPrice(&AssetPriceReq{AssetType: "stock", AssetCode: "7203.T", FromDate: "2021-09-25"}, "2021-09-24"); !errors.As(err, &validationError) {
+		t.Errorf("from > to: error = %v, want ValidationError", err)
+	}
+}
@@ -0,0 +1,368 @@
+package models
+
+import (
+	"code/config"
+	"code/decimal"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// 日付毎の資産価値と損益
+type AssetTransition struct {
+	Date  string
+	Value decimal.Decimal
+	// 損益（受取済みの配当金・分配金を含む）
+	Profit decimal.Decimal
+	// 受取済みの配当金・分配金の累計
+	Income decimal.Decimal
+	// 最初の日付からの時間加重収益率（ベンチマーク指定時の全資産合計のみ）
+	Return *float64 `json:",omitempty"`
+	// 最初の日付からのベンチマークの収益率（ベンチマーク指定時の全資産合計のみ）
+	BenchmarkReturn *float64 `json:",omitempty"`
+}
+
+// 資産毎・カテゴリー毎の資産価値と損益の推移
+type AssetTransitionSeries struct {
+	// 資産コードまたはカテゴリーID
+	Code           string
+	Name           string
+	TransitionList []AssetTransition
+}
+
+// 全資産合計・資産毎・カテゴリー毎の資産価値と損益の推移
+type AssetTransitionBreakdown struct {
+	Total    []AssetTransition
+	Asset    []AssetTransitionSeries
+	Category []AssetTransitionSeries
+}
+
+// 資産毎の評価に必要なデータ
+type assetValuation struct {
+	assetMaster AssetMaster
+	// 基準通貨に換算した取引データ
+	assetBuyList []AssetBuy
+	// 基準通貨に換算した配当金・分配金
+	distributionList []AssetDistribution
+	holdingHistory   AssetHoldingHistory
+	priceSeries      PriceSeries
+	fxRateList       FxRateList
+}
+
+// 期間の指定がない場合に返す推移の件数
+const defaultTransitionCount = 100
+
+/*
+ * 全資産合計の資産価値と損益の推移を算出
+ * 期間・集計間隔・ベンチマークの指定は CalcAssetTransitionBreakdown と同じ
+ */
+func CalcAssetTransition(assetBuyList []AssetBuy, fromDate string, toDate string, interval string, benchmark string) ([]AssetTransition, error) {
+	breakdown, err := CalcAssetTransitionBreakdown(assetBuyList, fromDate, toDate, interval, benchmark)
+	return breakdown.Total, err
+}
+
+/*
+ * 全資産合計・資産毎・カテゴリー毎の資産価値と損益の推移を算出
+ * fromDate, toDate(yyyy-mm-dd) で期間を指定する（開始日の指定がない場合は直近100件）
+ * interval で集計間隔（日次・週次・月次）を指定し、週次・月次は各期間の最終日の値を返す
+ * 取引市場の異なる資産の価格は日付で揃え、休場日は直前の価格で補完する
+ * benchmark を指定した場合、全資産合計の推移に最初の日付からの収益率とベンチマークの収益率を含める
+ */
+func CalcAssetTransitionBreakdown(assetBuyList []AssetBuy, fromDate string, toDate string, interval string, benchmark string) (AssetTransitionBreakdown, error) {
+	var breakdown AssetTransitionBreakdown
+	if interval == "" {
+		interval = config.TRANSITION_INTERVAL_DAILY
+	}
+	if err := validateTransitionQuery(fromDate, toDate, interval); err != nil {
+		return breakdown, err
+	}
+
+	valuationList, err := loadAssetValuationList(assetBuyList)
+	if err != nil {
+		return breakdown, err
+	}
+
+	// 期間で絞り込み、集計間隔毎の最終日を抽出する
+	var dateList []string
+	for _, date := range transitionDateList(valuationList) {
+		if (fromDate == "" || date >= fromDate) && (toDate == "" || date <= toDate) {
+			dateList = append(dateList, date)
+		}
+	}
+	dailyDateList := dateList
+	dateList = sampleDateList(dateList, interval)
+	if fromDate == "" && len(dateList) > defaultTransitionCount {
+		dateList = dateList[len(dateList)-defaultTransitionCount:]
+	}
+
+	// ベンチマークとの比較用に、日次で算出した基準価額を日付毎に保持する
+	portfolioIndexByDate := make(map[string]float64)
+	benchmarkIndexByDate := make(map[string]float64)
+	if benchmark != "" {
+		indexDateList, portfolioIndexList, benchmarkIndexList, err := calcPortfolioBenchmarkIndex(valuationList, dailyDateList, benchmark)
+		if err != nil {
+			return breakdown, err
+		}
+		for idx, date := range indexDateList {
+			portfolioIndexByDate[date] = portfolioIndexList[idx]
+			benchmarkIndexByDate[date] = benchmarkIndexList[idx]
+		}
+	}
+
+	// カテゴリー毎の推移（積み上げグラフで使えるよう、保有のないカテゴリーも含める）
+	categoryIndex := make(map[string]int)
+	var categoryIdList []int
+	for categoryId := range config.ASSET_CATEGORY_LIST {
+		categoryIdList = append(categoryIdList, categoryId)
+	}
+	sort.Ints(categoryIdList)
+	for _, categoryId := range categoryIdList {
+		code := strconv.Itoa(categoryId)
+		categoryIndex[code] = len(breakdown.Category)
+		breakdown.Category = append(breakdown.Category,
+			AssetTransitionSeries{Code: code, Name: config.ASSET_CATEGORY_LIST[categoryId]})
+	}
+	for _, valuation := range valuationList {
+		if _, ok := categoryIndex[valuation.assetMaster.CategoryId]; !ok {
+			categoryIndex[valuation.assetMaster.CategoryId] = len(breakdown.Category)
+			breakdown.Category = append(breakdown.Category, AssetTransitionSeries{Code: valuation.assetMaster.CategoryId})
+		}
+	}
+
+	// 資産毎の推移
+	for _, valuation := range valuationList {
+		breakdown.Asset = append(breakdown.Asset,
+			AssetTransitionSeries{Code: valuation.assetMaster.AssetCode, Name: valuation.assetMaster.Name})
+	}
+
+	for _, date := range dateList {
+		total := AssetTransition{Date: date}
+		categoryTransitionList := make([]AssetTransition, len(breakdown.Category))
+		for idx := range categoryTransitionList {
+			categoryTransitionList[idx].Date = date
+		}
+		for idx, valuation := range valuationList {
+			value, profit, err := valuation.valueAt(date)
+			if err != nil {
+				return breakdown, err
+			}
+			income := valuation.incomeAt(date)
+			total.Value = total.Value.Add(value)
+			total.Profit = total.Profit.Add(profit)
+			total.Income = total.Income.Add(income)
+
+			breakdown.Asset[idx].TransitionList = append(breakdown.Asset[idx].TransitionList,
+				AssetTransition{Date: date, Value: value, Profit: profit, Income: income})
+
+			category := &categoryTransitionList[categoryIndex[valuation.assetMaster.CategoryId]]
+			category.Value = category.Value.Add(value)
+			category.Profit = category.Profit.Add(profit)
+			category.Income = category.Income.Add(income)
+		}
+		setTransitionReturn(&total, breakdown.Total, portfolioIndexByDate, benchmarkIndexByDate)
+		breakdown.Total = append(breakdown.Total, total)
+		for idx, transition := range categoryTransitionList {
+			breakdown.Category[idx].TransitionList = append(breakdown.Category[idx].TransitionList, transition)
+		}
+	}
+	return breakdown, nil
+}
+
+// 基準価額のある最初の推移を基準に、収益率とベンチマークの収益率を設定
+func setTransitionReturn(transition *AssetTransition, previousList []AssetTransition, portfolioIndexByDate map[string]float64, benchmarkIndexByDate map[string]float64) {
+	portfolioIndex, ok := portfolioIndexByDate[transition.Date]
+	if !ok {
+		return
+	}
+	baseDate := transition.Date
+	for _, previous := range previousList {
+		if previous.Return != nil {
+			baseDate = previous.Date
+			break
+		}
+	}
+	portfolioReturn := portfolioIndex/portfolioIndexByDate[baseDate] - 1
+	benchmarkReturn := benchmarkIndexByDate[transition.Date]/benchmarkIndexByDate[baseDate] - 1
+	transition.Return = &portfolioReturn
+	transition.BenchmarkReturn = &benchmarkReturn
+}
+
+// 推移の取得条件の確認
+func validateTransitionQuery(fromDate string, toDate string, interval string) error {
+	for _, date := range []string{fromDate, toDate} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse(dateLayout, date); err != nil {
+			return newValidationError("invalid date: " + date)
+		}
+	}
+	if fromDate != "" && toDate != "" && fromDate > toDate {
+		return newValidationError("from date is after to date")
+	}
+	switch interval {
+	case config.TRANSITION_INTERVAL_DAILY, config.TRANSITION_INTERVAL_WEEKLY, config.TRANSITION_INTERVAL_MONTHLY:
+		return nil
+	}
+	return newValidationError("invalid interval: " + interval)
+}
+
+// 日付順の日付一覧から、集計間隔毎の最終日を抽出
+func sampleDateList(dateList []string, interval string) []string {
+	if interval == config.TRANSITION_INTERVAL_DAILY {
+		return dateList
+	}
+	var sampledList []string
+	lastPeriod := ""
+	for _, date := range dateList {
+		period := date[:7]
+		if interval == config.TRANSITION_INTERVAL_WEEKLY {
+			t, _ := time.Parse(dateLayout, date)
+			year, week := t.ISOWeek()
+			period = fmt.Sprintf("%d-W%02d", year, week)
+		}
+		// 同じ期間の日付は後の日付で上書きする
+		if period == lastPeriod {
+			sampledList[len(sampledList)-1] = date
+			continue
+		}
+		sampledList = append(sampledList, date)
+		lastPeriod = period
+	}
+	return sampledList
+}
+
+// 取引データのある資産毎に、資産マスタ・保有状況の推移・価格・為替レートを取得
+func loadAssetValuationList(assetBuyList []AssetBuy) ([]assetValuation, error) {
+	// AssetCode毎にリストを格納
+	assetBuyDataByAssetCode := make(map[string][]AssetBuy)
+	var assetCodeList []string
+	for _, data := range assetBuyList {
+		if _, ok := assetBuyDataByAssetCode[data.AssetCode]; !ok {
+			assetCodeList = append(assetCodeList, data.AssetCode)
+		}
+		assetBuyDataByAssetCode[data.AssetCode] = append(assetBuyDataByAssetCode[data.AssetCode], data)
+	}
+	sort.Strings(assetCodeList)
+
+	// 通貨毎の為替レート
+	fxRateListByCurrency := make(map[string]FxRateList)
+	var valuationList []assetValuation
+	for _, assetCode := range assetCodeList {
+		assetMaster, err := GetAssetMasterByAssetCodeAndCategoryId(assetCode, "")
+		if err != nil {
+			return nil, err
+		}
+		// 保有状況の集計と同じく、資産マスタのない資産は推移に含めない
+		if len(assetMaster) == 0 {
+			log.Printf("asset master not found: %s", assetCode)
+			continue
+		}
+		valuation := assetValuation{assetMaster: assetMaster[0]}
+
+		// 資産の通貨から基準通貨への為替レートを取得
+		currency := AssetCurrency(assetMaster[0])
+		fxRateList, ok := fxRateListByCurrency[currency]
+		if !ok {
+			fxRateList, err = GetFxRateList(currency)
+			if err != nil {
+				return nil, err
+			}
+			fxRateListByCurrency[currency] = fxRateList
+		}
+		valuation.fxRateList = fxRateList
+
+		// 取引を日付順に再生し、各日付時点の保有口数と取得価額を算出する
+		// 取得価額は取引日の為替レートで基準通貨に換算して算出する
+		if valuation.assetBuyList, err = ConvertAssetBuyList(assetBuyDataByAssetCode[assetCode], fxRateList); err != nil {
+			return nil, err
+		}
+		if valuation.holdingHistory, err = CalcAssetHoldingHistory(valuation.assetBuyList, BaseCurrency()); err != nil {
+			return nil, err
+		}
+
+		// 配当金・分配金は受取日の為替レートで基準通貨に換算する
+		distributionList, err := GetAssetDistributionList(assetCode)
+		if err != nil {
+			return nil, err
+		}
+		if valuation.distributionList, err = ConvertAssetDistributionList(distributionList, fxRateList); err != nil {
+			return nil, err
+		}
+
+		// 現金は価格を参照しない
+		if assetMaster[0].Type != config.ASSET_TYPE_CACHE {
+			priceList, err := GetAssetPriceByAssetCodeAndDate(assetCode, "", "")
+			if err != nil {
+				return nil, err
+			}
+			valuation.priceSeries = NewPriceSeries(priceList)
+		}
+		valuationList = append(valuationList, valuation)
+	}
+	return valuationList, nil
+}
+
+// 推移の日付一覧（いずれかの資産の価格がある日・取引日・配当金等の受取日を、最初の取引日以降で日付順に並べる）
+func transitionDateList(valuationList []assetValuation) []string {
+	firstDate := ""
+	dateMap := make(map[string]bool)
+	for _, valuation := range valuationList {
+		if date := valuation.holdingHistory.FirstDate(); date != "" && (firstDate == "" || date < firstDate) {
+			firstDate = date
+		}
+		for _, date := range valuation.priceSeries.DateList() {
+			dateMap[date] = true
+		}
+		for _, date := range valuation.holdingHistory.dateList {
+			dateMap[date] = true
+		}
+		for _, data := range valuation.distributionList {
+			dateMap[data.Date] = true
+		}
+	}
+
+	var dateList []string
+	for date := range dateMap {
+		if date >= firstDate {
+			dateList = append(dateList, date)
+		}
+	}
+	sort.Strings(dateList)
+	return dateList
+}
+
+// 指定日時点の評価額と損益（基準通貨。損益は売却済みの実現損益と受取済みの配当金・分配金を含む）
+func (valuation assetValuation) valueAt(date string) (decimal.Decimal, decimal.Decimal, error) {
+	holding := valuation.holdingHistory.HoldingAt(date)
+	// 現金の場合、価格一覧を参照せずに評価額を算出する（損益なし）
+	if valuation.assetMaster.Type == config.ASSET_TYPE_CACHE {
+		value, err := valuation.fxRateList.Convert(holding.Unit, date)
+		return value, decimal.Zero, err
+	}
+	price, ok := valuation.priceSeries.PriceAt(date)
+	if !ok {
+		return decimal.Zero, decimal.Zero, nil
+	}
+	value, err := CalcPresentValue(price, holding.Unit, valuation.assetMaster)
+	if err != nil {
+		return decimal.Zero, decimal.Zero, err
+	}
+	if value, err = valuation.fxRateList.Convert(value, date); err != nil {
+		return decimal.Zero, decimal.Zero, err
+	}
+	return value, value.Sub(holding.Amount).Add(holding.RealizedProfit).Add(valuation.incomeAt(date)), nil
+}
+
+// 指定日までに受け取った配当金・分配金の累計（基準通貨）
+func (valuation assetValuation) incomeAt(date string) decimal.Decimal {
+	income := decimal.Zero
+	for _, data := range valuation.distributionList {
+		if data.Date <= date {
+			income = income.Add(data.Amount)
+		}
+	}
+	return income
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"code/config"
+	"errors"
+	"testing"
+)
+
+func TestCalcAssetTransitionBreakdown(t *testing.T) {
+	defer SetRepository(SetRepository(NewMemoryRepository()))
+	saveTestAssetMasterAndPrice(t)
+	saveTestStockTrade(t)
+	assetBuyList, err := GetAssetBuyByAssetCode("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	breakdown, err := CalcAssetTransitionBreakdown(assetBuyList, "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 休場日（09/23）は推移に含めない
+	// 09/24 の損益は売却した100株の実現損益15000円と残り100株の含み益15000円の合計
+	want := []struct {
+		date, value, profit string
+	}{
+		{"2021-09-21", "100000", "0"},
+		{"2021-09-22", "220000", "10000"},
+		{"2021-09-24", "120000", "30000"},
+	}
+	if len(breakdown.Total) != len(want) {
+		t.Fatalf("Total = %+v, want %d dates", breakdown.Total, len(want))
+	}
+	for idx, w := range want {
+		got := breakdown.Total[idx]
+		if got.Date != w.date || got.Value.String() != w.value || got.Profit.String() != w.profit {
+			t.Errorf("Total[%d] = %s %s %s, want %s %s %s", idx, got.Date, got.Value, got.Profit, w.date, w.value, w.profit)
+		}
+	}
+	if len(breakdown.Asset) != 1 || breakdown.Asset[0].Code != "7203.T" {
+		t.Errorf("Asset = %+v, want 7203.T only", breakdown.Asset)
+	}
+	// 保有のないカテゴリーも含め、国内株に集計する
+	if len(breakdown.Category) != len(config.ASSET_CATEGORY_LIST) || breakdown.Category[0].Code != "1" ||
+		breakdown.Category[0].TransitionList[2].Value.String() != "120000" || !breakdown.Category[1].TransitionList[2].Value.IsZero() {
+		t.Errorf("Category = %+v", breakdown.Category)
+	}
+
+	// 期間と集計間隔の指定
+	transitionList, err := CalcAssetTransition(assetBuyList, "2021-09-22", "2021-09-24", config.TRANSITION_INTERVAL_WEEKLY, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transitionList) != 1 || transitionList[0].Date != "2021-09-24" {
+		t.Errorf("weekly transition = %+v, want 2021-09-24 only", transitionList)
+	}
+}
+
+// 資産マスタのない資産の取引は推移に含めない
+func TestCalcAssetTransitionSkipsMissingAssetMaster(t *testing.T) {
+	defer SetRepository(SetRepository(NewMemoryRepository()))
+	saveTestAssetMasterAndPrice(t)
+	saveTestStockTrade(t)
+	orphan := AssetBuy{AssetCode: "DELETED", TransactionId: "2021-09-21#01", Date: "2021-09-21",
+		TradeType: config.TRADE_TYPE_BUY, Unit: mustDecimal(t, "10"), Amount: mustDecimal(t, "5000")}
+	if err := repository.AssetBuy.Save(orphan); err != nil {
+		t.Fatal(err)
+	}
+	assetBuyList, err := GetAssetBuyByAssetCode("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	breakdown, err := CalcAssetTransitionBreakdown(assetBuyList, "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(breakdown.Asset) != 1 || breakdown.Asset[0].Code != "7203.T" {
+		t.Errorf("Asset = %+v, want 7203.T only", breakdown.Asset)
+	}
+	if len(breakdown.Total) != 3 || breakdown.Total[0].Value.String() != "100000" {
+		t.Errorf("Total = %+v, want 7203.T only", breakdown.Total)
+	}
+}
+
+// 期間・集計間隔の誤りは入力内容の誤りとする
+func TestCalcAssetTransitionInvalidQuery(t *testing.T) {
+	defer SetRepository(SetRepository(NewMemoryRepository()))
+	cases := []struct {
+		fromDate, toDate, interval string
+	}{
+		{"2021-13-01", "", ""},
+		{"", "20210924", ""},
+		{"2021-09-24", "2021-09-21", ""},
+		{"", "", "yearly"},
+	}
+	var validationError *ValidationError
+	for _, c := range cases {
+		if _, err := CalcAssetTransitionBreakdown(nil, c.fromDate, c.toDate, c.interval, ""); !errors.As(err, &validationError) {
+			t.Errorf("CalcAssetTransitionBreakdown(%s, %s, %s) error = %v, want ValidationError", c.fromDate, c.toDate, c.interval, err)
+		}
+	}
+}
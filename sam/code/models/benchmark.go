@@ -0,0 +1,194 @@
+package models
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ベンチマークの構成銘柄
+type BenchmarkComponent struct {
+	AssetCode string
+	// 構成比率（合計1に正規化）
+	Weight float64
+}
+
+// ポートフォリオとベンチマークの比較（算出できない項目は nil）
+type BenchmarkComparison struct {
+	// ベンチマークの指定（ex: ^GSPC, ^GSPC:0.6,^TPX:0.4）
+	Benchmark string
+	FromDate  string
+	ToDate    string
+	// 期間の時間加重収益率
+	PortfolioReturn *float64
+	// ベンチマークの期間収益率
+	BenchmarkReturn *float64
+	// ジェンセンのアルファ（年率。無リスク金利を差し引いた超過リターンで算出）
+	Alpha *float64
+	// ベータ
+	Beta *float64
+	// トラッキングエラー（年率）
+	TrackingError *float64
+}
+
+// ベンチマークの構成銘柄毎の価格と為替レート
+type benchmarkSeries struct {
+	componentList   []BenchmarkComponent
+	priceSeriesList []PriceSeries
+	fxRateListList  []FxRateList
+}
+
+/*
+ * ベンチマークの指定を構成銘柄に分解
+ * "資産コード" または "資産コード:比率,資産コード:比率" の形式で指定し、比率は合計1に正規化する
+ */
+func ParseBenchmark(benchmark string) ([]BenchmarkComponent, error) {
+	var componentList []BenchmarkComponent
+	totalWeight := 0.0
+	for _, part := range strings.Split(benchmark, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		component := BenchmarkComponent{AssetCode: part, Weight: 1}
+		// 資産コードに ":" を含まない前提で、最後の ":" 以降を比率とする
+		if idx := strings.LastIndex(part, ":"); idx >= 0 {
+			weight, err := strconv.ParseFloat(part[idx+1:], 64)
+			if err != nil || weight <= 0 {
+				return nil, newValidationError("invalid benchmark weight: " + part)
+			}
+			component = BenchmarkComponent{AssetCode: part[:idx], Weight: weight}
+		}
+		totalWeight = totalWeight + component.Weight
+		componentList = append(componentList, component)
+	}
+	if len(componentList) == 0 {
+		return nil, newValidationError("no benchmark specified")
+	}
+	for idx := range componentList {
+		componentList[idx].Weight = componentList[idx].Weight / totalWeight
+	}
+	return componentList, nil
+}
+
+// ベンチマークの構成銘柄の価格と為替レートを取得
+func loadBenchmarkSeries(benchmark string) (benchmarkSeries, error) {
+	var series benchmarkSeries
+	componentList, err := ParseBenchmark(benchmark)
+	if err != nil {
+		return series, err
+	}
+	series.componentList = componentList
+	for _, component := range componentList {
+		assetMaster, err := GetAssetMasterByAssetCodeAndCategoryId(component.AssetCode, "")
+		if err != nil {
+			return series, err
+		}
+		if len(assetMaster) == 0 {
+			return series, newValidationError("benchmark not found: " + component.AssetCode)
+		}
+		priceList, err := GetAssetPriceByAssetCodeAndDate(component.AssetCode, "", "")
+		if err != nil {
+			return series, err
+		}
+		if len(priceList) == 0 {
+			return series, newValidationError("benchmark price not found: " + component.AssetCode)
+		}
+		fxRateList, err := GetFxRateList(AssetCurrency(assetMaster[0]))
+		if err != nil {
+			return series, err
+		}
+		series.priceSeriesList = append(series.priceSeriesList, NewPriceSeries(priceList))
+		series.fxRateListList = append(series.fxRateListList, fxRateList)
+	}
+	return series, nil
+}
+
+// 先頭の日付を1としたベンチマークの水準（基準通貨換算。複数銘柄の場合は日次で比率を保つ合成指数）
+func (series benchmarkSeries) indexList(dateList []string) []float64 {
+	var indexList []float64
+	level := 1.0
+	for idx, date := range dateList {
+		if idx > 0 {
+			dailyReturn := 0.0
+			for componentIdx, component := range series.componentList {
+				previous := series.levelAt(componentIdx, dateList[idx-1])
+				current := series.levelAt(componentIdx, date)
+				if previous > 0 {
+					dailyReturn = dailyReturn + component.Weight*(current/previous-1)
+				}
+			}
+			level = level * (1 + dailyReturn)
+		}
+		indexList = append(indexList, level)
+	}
+	return indexList
+}
+
+// 構成銘柄の指定日の価格（基準通貨換算。価格がない場合は0）
+func (series benchmarkSeries) levelAt(componentIdx int, date string) float64 {
+	price, ok := series.priceSeriesList[componentIdx].PriceAt(date)
+	if !ok {
+		return 0
+	}
+	return price.Float64() * series.fxRateListList[componentIdx].Rate(date).Float64()
+}
+
+// ポートフォリオとベンチマークの水準（同じ日付で揃えたもの）から比較指標を算出
+// riskFreeRate は年率の無リスク金利で、アルファの算出に使用する
+func calcBenchmarkComparison(benchmark string, dateList []string, portfolioIndexList []float64, benchmarkIndexList []float64, riskFreeRate float64) BenchmarkComparison {
+	comparison := BenchmarkComparison{Benchmark: benchmark}
+	if len(dateList) < 2 {
+		return comparison
+	}
+	comparison.FromDate = dateList[0]
+	comparison.ToDate = dateList[len(dateList)-1]
+
+	portfolioReturn := portfolioIndexList[len(portfolioIndexList)-1]/portfolioIndexList[0] - 1
+	benchmarkReturn := benchmarkIndexList[len(benchmarkIndexList)-1]/benchmarkIndexList[0] - 1
+	comparison.PortfolioReturn = &portfolioReturn
+	comparison.BenchmarkReturn = &benchmarkReturn
+
+	portfolioReturnList := alignedReturnList(portfolioIndexList)
+	benchmarkReturnList := alignedReturnList(benchmarkIndexList)
+	covariance, _, ok := calcCovariance(portfolioReturnList, benchmarkReturnList)
+	if !ok {
+		return comparison
+	}
+	portfolioMean, _ := meanStdev(portfolioReturnList)
+	benchmarkMean, benchmarkStdev := meanStdev(benchmarkReturnList)
+	if benchmarkStdev > 0 {
+		beta := covariance / (benchmarkStdev * benchmarkStdev)
+		// 日次の無リスク金利を差し引いた超過リターンで算出する
+		dailyRiskFreeRate := riskFreeRate / tradingDaysPerYear
+		alpha := ((portfolioMean - dailyRiskFreeRate) - beta*(benchmarkMean-dailyRiskFreeRate)) * tradingDaysPerYear
+		comparison.Beta = &beta
+		comparison.Alpha = &alpha
+	}
+
+	// 超過リターンの標準偏差を年率換算
+	var excessReturnList []float64
+	for idx := range portfolioReturnList {
+		excessReturnList = append(excessReturnList, portfolioReturnList[idx]-benchmarkReturnList[idx])
+	}
+	_, excessStdev := meanStdev(excessReturnList)
+	trackingError := excessStdev * math.Sqrt(tradingDaysPerYear)
+	comparison.TrackingError = &trackingError
+	return comparison
+}
+
+// 全資産合計の時間加重の基準価額とベンチマークの水準を、保有開始日以降の日付で揃えて算出
+func calcPortfolioBenchmarkIndex(valuationList []assetValuation, dateList []string, benchmark string) ([]string, []float64, []float64, error) {
+	series, err := loadBenchmarkSeries(benchmark)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	valueList, flowList, start, err := groupValueFlowList(valuationList, dateList)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if start >= len(dateList) {
+		return nil, nil, nil, nil
+	}
+	return dateList[start:], twrIndexList(valueList, flowList, start), series.indexList(dateList[start:]), nil
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseBenchmark(t *testing.T) {
+	componentList, err := ParseBenchmark("^GSPC:3, ^TPX:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(componentList) != 2 || componentList[0].AssetCode != "^GSPC" || componentList[0].Weight != 0.75 ||
+		componentList[1].AssetCode != "^TPX" || componentList[1].Weight != 0.25 {
+		t.Errorf("ParseBenchmark = %+v, want ^GSPC: 0.75, ^TPX: 0.25", componentList)
+	}
+
+	var validationError *ValidationError
+	for _, benchmark := range []string{"", " , ", "^GSPC:0", "^GSPC:-1", "^GSPC:abc"} {
+		if _, err := ParseBenchmark(benchmark); !errors.As(err, &validationError) {
+			t.Errorf("ParseBenchmark(%q) error = %v, want ValidationError", benchmark, err)
+		}
+	}
+}
+
+// アルファは無リスク金利を差し引いた超過リターンで算出する
+func TestCalcBenchmarkComparisonAlpha(t *testing.T) {
+	// ポートフォリオの日次リターンはベンチマークの2倍（ベータ2）
+	benchmarkReturnList := []float64{0.01, -0.02, 0.015, 0.005, -0.01}
+	dateList := []string{"2021-09-17", "2021-09-21", "2021-09-22", "2021-09-24", "2021-09-27", "2021-09-28"}
+	portfolioIndexList := []float64{1}
+	benchmarkIndexList := []float64{1}
+	for idx, benchmarkReturn := range benchmarkReturnList {
+		portfolioIndexList = append(portfolioIndexList, portfolioIndexList[idx]*(1+2*benchmarkReturn))
+		benchmarkIndexList = append(benchmarkIndexList, benchmarkIndexList[idx]*(1+benchmarkReturn))
+	}
+
+	cases := []struct {
+		riskFreeRate float64
+		alpha        float64
+	}{
+		{0, 0},
+		// (rp - rf) - 2(rb - rf) = rp - 2rb + rf のため、アルファは無リスク金利と一致する
+		{0.02, 0.02},
+	}
+	for _, c := range cases {
+		comparison := calcBenchmarkComparison("^GSPC", dateList, portfolioIndexList, benchmarkIndexList, c.riskFreeRate)
+		if comparison.Beta == nil || math.Abs(*comparison.Beta-2) > 1e-9 {
+			t.Errorf("riskFreeRate %v: Beta = %v, want 2", c.riskFreeRate, comparison.Beta)
+		}
+		if comparison.Alpha == nil || math.Abs(*comparison.Alpha-c.alpha) > 1e-9 {
+			t.Errorf("riskFreeRate %v: Alpha = %v, want %v", c.riskFreeRate, comparison.Alpha, c.alpha)
+		}
+	}
+}
+
+// 未登録のベンチマークは入力内容の誤りとする
+func TestCalcPerformanceUnknownBenchmark(t *testing.T) {
+	defer SetRepository(SetRepository(NewMemoryRepository()))
+	saveTestAssetMasterAndPrice(t)
+	saveTestStockTrade(t)
+	assetBuyList, err := GetAssetBuyByAssetCode("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var validationError *ValidationError
+	if _, err := CalcPerformance(assetBuyList, "", "", "^UNKNOWN", 0); !errors.As(err, &validationError) {
+		t.Errorf("error = %v, want ValidationError", err)
+	}
+}
@@ -2,24 +2,33 @@ package models
 
 import (
 	"os"
+	"sync"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/guregu/dynamo"
 )
 
+// Dynamodb接続（Lambdaの実行環境が再利用される間は同じ接続を使う）
+var (
+	dynamodb     *dynamo.DB
+	dynamodbOnce sync.Once
+)
+
 /*
  * Dynamodb接続設定
  */
 func connectDynamodb(table string) dynamo.Table {
-	// Endpoint設定(Local Dynamodb接続用)
-	endpoint := os.Getenv("DYNAMODB_ENDPOINT")
-	// Dynamodb接続設定
-	session := session.Must(session.NewSession())
-	config := aws.NewConfig().WithRegion("ap-northeast-1")
-	if len(endpoint) > 0 {
-		config = config.WithEndpoint(endpoint)
-	}
-	db := dynamo.New(session, config)
-	return db.Table(table)
+	dynamodbOnce.Do(func() {
+		// Endpoint設定(Local Dynamodb接続用)
+		endpoint := os.Getenv("DYNAMODB_ENDPOINT")
+		// Dynamodb接続設定
+		session := session.Must(session.NewSession())
+		config := aws.NewConfig().WithRegion("ap-northeast-1")
+		if len(endpoint) > 0 {
+			config = config.WithEndpoint(endpoint)
+		}
+		dynamodb = dynamo.New(session, config)
+	})
+	return dynamodb.Table(table)
 }
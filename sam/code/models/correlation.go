@@ -0,0 +1,148 @@
+package models
+
+import (
+	"code/config"
+	"math"
+	"sort"
+	"time"
+)
+
+// 保有資産間の相関行列・共分散行列（算出できない要素は nil）
+type CorrelationMatrix struct {
+	AssetCodeList []string
+	AssetNameList []string
+	FromDate      string
+	ToDate        string
+	// 日次リターンの件数
+	ObservationCount int
+	// 日次リターンの相関係数
+	Correlation [][]*float64
+	// 日次リターンの共分散
+	Covariance [][]*float64
+}
+
+/*
+ * 現在保有している資産の日次リターンから、相関行列と共分散行列を算出
+ * lookbackDays で最新の共通日から遡る日数を指定する
+ * 各資産の価格は基準通貨に換算し、全資産の価格がある日付で揃えてリターンを算出する
+ */
+func CalcCorrelationMatrix(assetBuyList []AssetBuy, lookbackDays int) (CorrelationMatrix, error) {
+	var matrix CorrelationMatrix
+	if lookbackDays <= 0 {
+		return matrix, newValidationError("lookback days must be positive")
+	}
+	valuationList, err := loadAssetValuationList(assetBuyList)
+	if err != nil {
+		return matrix, err
+	}
+
+	// 現在保有している資産（現金は対象外）
+	var heldList []assetValuation
+	for _, valuation := range valuationList {
+		if valuation.assetMaster.Type == config.ASSET_TYPE_CACHE {
+			continue
+		}
+		if valuation.holdingHistory.Latest().Unit.Sign() > 0 {
+			heldList = append(heldList, valuation)
+		}
+	}
+	for _, valuation := range heldList {
+		matrix.AssetCodeList = append(matrix.AssetCodeList, valuation.assetMaster.AssetCode)
+		matrix.AssetNameList = append(matrix.AssetNameList, valuation.assetMaster.Name)
+	}
+	if len(heldList) == 0 {
+		return matrix, nil
+	}
+
+	// 全資産の価格がある日付
+	dateCount := make(map[string]int)
+	for _, valuation := range heldList {
+		for _, date := range valuation.priceSeries.DateList() {
+			dateCount[date]++
+		}
+	}
+	var commonDateList []string
+	for date, count := range dateCount {
+		if count == len(heldList) {
+			commonDateList = append(commonDateList, date)
+		}
+	}
+	sort.Strings(commonDateList)
+	if len(commonDateList) == 0 {
+		return matrix, nil
+	}
+
+	// 最新の共通日から遡って期間を決める
+	latest, _ := time.Parse(dateLayout, commonDateList[len(commonDateList)-1])
+	fromDate := latest.AddDate(0, 0, -lookbackDays).Format(dateLayout)
+	var dateList []string
+	for _, date := range commonDateList {
+		if date >= fromDate {
+			dateList = append(dateList, date)
+		}
+	}
+	matrix.FromDate = dateList[0]
+	matrix.ToDate = dateList[len(dateList)-1]
+
+	// 資産毎の日次リターン（基準通貨換算）
+	returnListByAsset := make([][]float64, len(heldList))
+	for idx, valuation := range heldList {
+		var levelList []float64
+		for _, date := range dateList {
+			price, _ := valuation.priceSeries.PriceAt(date)
+			levelList = append(levelList, price.Float64()*valuation.fxRateList.Rate(date).Float64())
+		}
+		returnListByAsset[idx] = alignedReturnList(levelList)
+	}
+	matrix.ObservationCount = len(returnListByAsset[0])
+
+	size := len(heldList)
+	matrix.Correlation = make([][]*float64, size)
+	matrix.Covariance = make([][]*float64, size)
+	for i := 0; i < size; i++ {
+		matrix.Correlation[i] = make([]*float64, size)
+		matrix.Covariance[i] = make([]*float64, size)
+		for j := 0; j < size; j++ {
+			covariance, correlation, ok := calcCovariance(returnListByAsset[i], returnListByAsset[j])
+			if !ok {
+				continue
+			}
+			matrix.Covariance[i][j] = &covariance
+			if !math.IsNaN(correlation) {
+				matrix.Correlation[i][j] = &correlation
+			}
+		}
+	}
+	return matrix, nil
+}
+
+// 日付を揃えた水準の日次リターン（前日の水準が0以下の日は0とし、資産間で件数を揃える）
+func alignedReturnList(levelList []float64) []float64 {
+	var returnList []float64
+	for idx := 1; idx < len(levelList); idx++ {
+		if levelList[idx-1] <= 0 {
+			returnList = append(returnList, 0)
+			continue
+		}
+		returnList = append(returnList, levelList[idx]/levelList[idx-1]-1)
+	}
+	return returnList
+}
+
+// 標本共分散と相関係数（標準偏差が0の場合、相関係数は NaN）
+func calcCovariance(list1 []float64, list2 []float64) (float64, float64, bool) {
+	if len(list1) < 2 || len(list1) != len(list2) {
+		return 0, 0, false
+	}
+	mean1, stdev1 := meanStdev(list1)
+	mean2, stdev2 := meanStdev(list2)
+	sum := 0.0
+	for idx := range list1 {
+		sum = sum + (list1[idx]-mean1)*(list2[idx]-mean2)
+	}
+	covariance := sum / float64(len(list1)-1)
+	if stdev1 == 0 || stdev2 == 0 {
+		return covariance, math.NaN(), true
+	}
+	return covariance, covariance / (stdev1 * stdev2), true
+}
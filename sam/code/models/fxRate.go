@@ -0,0 +1,107 @@
+package models
+
+import (
+	"code/config"
+	"code/decimal"
+	"errors"
+	"os"
+)
+
+// 為替レートの一覧（日付順）
+type FxRateList struct {
+	// 基準通貨の場合は常に1
+	identity bool
+	series   PriceSeries
+}
+
+/*
+ * 基準通貨（環境変数 BASE_CURRENCY、未設定の場合は円）
+ */
+func BaseCurrency() string {
+	if currency := os.Getenv("BASE_CURRENCY"); currency != "" {
+		return currency
+	}
+	return config.CURRENCY_JPY
+}
+
+/*
+ * 資産の通貨（未設定の場合は円）
+ */
+func AssetCurrency(assetMaster AssetMaster) string {
+	if assetMaster.Currency == "" {
+		return config.CURRENCY_JPY
+	}
+	return assetMaster.Currency
+}
+
+/*
+ * 指定した通貨から基準通貨への為替レートを保存する資産コード（Yahoo Financeのシンボル ex: USDJPY=X）
+ */
+func FxAssetCode(currency string) string {
+	return currency + BaseCurrency() + "=X"
+}
+
+/*
+ * 為替レート取得用の資産マスタ（asset_master には登録せず、asset_daily にのみ保存する）
+ */
+func FxAssetMaster(currency string) AssetMaster {
+	return AssetMaster{AssetCode: FxAssetCode(currency), Name: currency + "/" + BaseCurrency(),
+		PriceProvider: config.PRICE_PROVIDER_YAHOO_FINANCE, Currency: BaseCurrency()}
+}
+
+/*
+ * 指定した通貨から基準通貨への為替レートを取得
+ */
+func GetFxRateList(currency string) (FxRateList, error) {
+	if currency == "" || currency == BaseCurrency() {
+		return FxRateList{identity: true}, nil
+	}
+	priceList, err := GetAssetPriceByAssetCodeAndDate(FxAssetCode(currency), "", "")
+	if err != nil {
+		return FxRateList{}, err
+	}
+	if len(priceList) == 0 {
+		return FxRateList{}, errors.New("fx rate not found: " + FxAssetCode(currency))
+	}
+	return FxRateList{series: NewPriceSeries(priceList)}, nil
+}
+
+/*
+ * 指定日の為替レート（休日など指定日のレートがない場合は直近のレート、それ以前のレートがない場合は最も古いレート）
+ */
+func (fxRateList FxRateList) Rate(date string) decimal.Decimal {
+	if fxRateList.identity || fxRateList.series.Len() == 0 {
+		return decimal.NewFromInt(1)
+	}
+	if rate, ok := fxRateList.series.PriceAt(date); ok {
+		return rate
+	}
+	return fxRateList.series.priceList[0]
+}
+
+/*
+ * 指定日の為替レートで金額を基準通貨に換算
+ */
+func (fxRateList FxRateList) Convert(amount decimal.Decimal, date string) (decimal.Decimal, error) {
+	if fxRateList.identity {
+		return amount, nil
+	}
+	converted, err := amount.Mul(fxRateList.Rate(date))
+	return RoundAmount(converted, BaseCurrency()), err
+}
+
+/*
+ * 取引データの金額を取引日の為替レートで基準通貨に換算
+ */
+func ConvertAssetBuyList(assetBuyList []AssetBuy, fxRateList FxRateList) ([]AssetBuy, error) {
+	convertedList := make([]AssetBuy, len(assetBuyList))
+	for idx, data := range assetBuyList {
+		amount, err := fxRateList.Convert(data.Amount, data.Date)
+		if err != nil {
+			return nil, err
+		}
+		data.Amount = amount
+		convertedList[idx] = data
+	}
+	return convertedList, nil
+}
@@ -0,0 +1,294 @@
+package models
+
+import (
+	"code/config"
+	"code/decimal"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// 収益率（算出できない場合は nil）
+type Performance struct {
+	// 資産コードまたはカテゴリーID（全資産合計の場合は空文字）
+	Code     string
+	Name     string
+	FromDate string
+	ToDate   string
+	// 時間加重収益率（期間）
+	Twr *float64
+	// 時間加重収益率（年率）
+	TwrAnnualized *float64
+	// 金額加重収益率（XIRR・年率）
+	Xirr *float64
+}
+
+// 全資産合計・資産毎・カテゴリー毎の収益率
+type PerformanceList struct {
+	Total    Performance
+	Asset    []Performance
+	Category []Performance
+	// ベンチマークとの比較（ベンチマーク指定時のみ）
+	Benchmark *BenchmarkComparison `json:",omitempty"`
+}
+
+// 入出金（投資家から見た金額。購入は負、売却・配当金・分配金・評価額は正）
+type cashFlow struct {
+	date   time.Time
+	amount float64
+}
+
+/*
+ * 取引データと価格から、時間加重収益率(TWR)と金額加重収益率(XIRR)を算出
+ * fromDate, toDate(yyyy-mm-dd) で期間を指定する（指定がない場合は最初の取引日から最新日まで）
+ * 期間の途中から算出する場合は、開始日の評価額を初期投資とみなす
+ * benchmark を指定した場合、全資産合計の時間加重収益率をベンチマークと比較する
+ * riskFreeRate は年率の無リスク金利（ex: 0.001）で、ベンチマークとの比較のアルファの算出に使用する
+ */
+func CalcPerformance(assetBuyList []AssetBuy, fromDate string, toDate string, benchmark string, riskFreeRate float64) (PerformanceList, error) {
+	var performanceList PerformanceList
+	dateList, valuationList, err := loadPeriodValuationList(assetBuyList, fromDate, toDate)
+	if err != nil {
+		return performanceList, err
+	}
+
+	// 全資産合計
+	if performanceList.Total, err = calcGroupPerformance(valuationList, dateList, fromDate != ""); err != nil {
+		return performanceList, err
+	}
+	if benchmark != "" {
+		indexDateList, portfolioIndexList, benchmarkIndexList, err := calcPortfolioBenchmarkIndex(valuationList, dateList, benchmark)
+		if err != nil {
+			return performanceList, err
+		}
+		comparison := calcBenchmarkComparison(benchmark, indexDateList, portfolioIndexList, benchmarkIndexList, riskFreeRate)
+		performanceList.Benchmark = &comparison
+	}
+
+	// 資産毎
+	valuationListByCategory := make(map[string][]assetValuation)
+	for _, valuation := range valuationList {
+		performance, err := calcGroupPerformance([]assetValuation{valuation}, dateList, fromDate != "")
+		if err != nil {
+			return performanceList, err
+		}
+		performance.Code = valuation.assetMaster.AssetCode
+		performance.Name = valuation.assetMaster.Name
+		performanceList.Asset = append(performanceList.Asset, performance)
+
+		categoryId := valuation.assetMaster.CategoryId
+		valuationListByCategory[categoryId] = append(valuationListByCategory[categoryId], valuation)
+	}
+
+	// カテゴリー毎
+	var categoryIdList []string
+	for categoryId := range valuationListByCategory {
+		categoryIdList = append(categoryIdList, categoryId)
+	}
+	sort.Strings(categoryIdList)
+	for _, categoryId := range categoryIdList {
+		performance, err := calcGroupPerformance(valuationListByCategory[categoryId], dateList, fromDate != "")
+		if err != nil {
+			return performanceList, err
+		}
+		performance.Code = categoryId
+		if id, err := strconv.Atoi(categoryId); err == nil {
+			performance.Name = config.ASSET_CATEGORY_LIST[id]
+		}
+		performanceList.Category = append(performanceList.Category, performance)
+	}
+	return performanceList, nil
+}
+
+// 期間内の日次の日付一覧と、資産毎の評価データを取得
+func loadPeriodValuationList(assetBuyList []AssetBuy, fromDate string, toDate string) ([]string, []assetValuation, error) {
+	if err := validateTransitionQuery(fromDate, toDate, config.TRANSITION_INTERVAL_DAILY); err != nil {
+		return nil, nil, err
+	}
+	valuationList, err := loadAssetValuationList(assetBuyList)
+	if err != nil {
+		return nil, nil, err
+	}
+	var dateList []string
+	for _, date := range transitionDateList(valuationList) {
+		if (fromDate == "" || date >= fromDate) && (toDate == "" || date <= toDate) {
+			dateList = append(dateList, date)
+		}
+	}
+	return dateList, valuationList, nil
+}
+
+// 資産のまとまり毎の収益率を算出
+// startFromValue が true の場合、開始日の評価額を初期投資とみなす（false の場合は開始日の取引を初期投資とする）
+func calcGroupPerformance(valuationList []assetValuation, dateList []string, startFromValue bool) (Performance, error) {
+	var performance Performance
+
+	// 日付毎の評価額と入出金（購入は正、売却・配当金・分配金は負）
+	valueList, flowList, start, err := groupValueFlowList(valuationList, dateList)
+	if err != nil || start >= len(dateList)-1 {
+		return performance, err
+	}
+	performance.FromDate = dateList[start]
+	performance.ToDate = dateList[len(dateList)-1]
+	fromTime, _ := time.Parse(dateLayout, performance.FromDate)
+	toTime, _ := time.Parse(dateLayout, performance.ToDate)
+
+	// 時間加重収益率
+	indexList := twrIndexList(valueList, flowList, start)
+	growth := indexList[len(indexList)-1]
+	twr := growth - 1
+	performance.Twr = &twr
+	if years := toTime.Sub(fromTime).Hours() / 24 / 365; years > 0 && growth > 0 {
+		twrAnnualized := math.Pow(growth, 1/years) - 1
+		performance.TwrAnnualized = &twrAnnualized
+	}
+
+	// 金額加重収益率（購入額を負、売却額・配当金・分配金と期末評価額を正の入出金としてXIRRを算出）
+	var flows []cashFlow
+	for idx := start; idx < len(dateList); idx++ {
+		date, _ := time.Parse(dateLayout, dateList[idx])
+		amount := -flowList[idx]
+		if idx == start && startFromValue {
+			amount = -valueList[idx]
+		}
+		if amount != 0 {
+			flows = append(flows, cashFlow{date: date, amount: amount})
+		}
+	}
+	flows = append(flows, cashFlow{date: toTime, amount: valueList[len(valueList)-1]})
+	if rate, ok := xirr(flows); ok {
+		performance.Xirr = &rate
+	}
+	return performance, nil
+}
+
+// 資産のまとまりの日付毎の評価額と入出金（購入は正、売却・配当金・分配金は負）
+// 保有を開始する前の日付を除いた開始位置も返す
+func groupValueFlowList(valuationList []assetValuation, dateList []string) ([]float64, []float64, int, error) {
+	var valueList []float64
+	var flowList []float64
+	for _, date := range dateList {
+		value := decimal.Zero
+		flow := decimal.Zero
+		for _, valuation := range valuationList {
+			v, _, err := valuation.valueAt(date)
+			if err != nil {
+				return nil, nil, 0, err
+			}
+			value = value.Add(v)
+			flow = flow.Add(valuation.netFlowAt(date))
+		}
+		valueList = append(valueList, value.Float64())
+		flowList = append(flowList, flow.Float64())
+	}
+
+	start := 0
+	for start < len(dateList) && valueList[start] == 0 && flowList[start] == 0 {
+		start++
+	}
+	return valueList, flowList, start, nil
+}
+
+// 開始位置を1とした時間加重の基準価額（日毎の収益率を連結する。入出金は当日の評価額に含まれるものとして除く）
+func twrIndexList(valueList []float64, flowList []float64, start int) []float64 {
+	indexList := []float64{1}
+	growth := 1.0
+	for idx := start + 1; idx < len(valueList); idx++ {
+		if valueList[idx-1] > 0 {
+			growth = growth * (valueList[idx] - flowList[idx]) / valueList[idx-1]
+		}
+		indexList = append(indexList, growth)
+	}
+	return indexList
+}
+
+// 指定日の入出金（基準通貨。購入は正、売却・配当金・分配金の受取は負）
+func (valuation assetValuation) netFlowAt(date string) decimal.Decimal {
+	flow := decimal.Zero
+	for _, data := range valuation.assetBuyList {
+		if data.Date != date {
+			continue
+		}
+		if data.TradeType == config.TRADE_TYPE_SELL {
+			flow = flow.Sub(data.Amount)
+		} else {
+			flow = flow.Add(data.Amount)
+		}
+	}
+	for _, data := range valuation.distributionList {
+		if data.Date == date {
+			flow = flow.Sub(data.Amount)
+		}
+	}
+	return flow
+}
+
+// 不定期な入出金の内部収益率(XIRR)を算出（ニュートン法で収束しない場合は二分法）
+func xirr(flows []cashFlow) (float64, bool) {
+	if len(flows) < 2 {
+		return 0, false
+	}
+	// 正負両方の入出金がない場合は算出できない
+	hasPositive, hasNegative := false, false
+	for _, flow := range flows {
+		hasPositive = hasPositive || flow.amount > 0
+		hasNegative = hasNegative || flow.amount < 0
+	}
+	if !hasPositive || !hasNegative {
+		return 0, false
+	}
+
+	first := flows[0].date
+	npv := func(rate float64) (float64, float64) {
+		value, derivative := 0.0, 0.0
+		for _, flow := range flows {
+			years := flow.date.Sub(first).Hours() / 24 / 365
+			discount := math.Pow(1+rate, years)
+			value = value + flow.amount/discount
+			derivative = derivative - years*flow.amount/(discount*(1+rate))
+		}
+		return value, derivative
+	}
+
+	// ニュートン法
+	rate := 0.1
+	for i := 0; i < 100; i++ {
+		value, derivative := npv(rate)
+		if math.Abs(value) < 1e-7 {
+			return rate, true
+		}
+		if derivative == 0 {
+			break
+		}
+		next := rate - value/derivative
+		if next <= -1 || math.IsNaN(next) || math.IsInf(next, 0) {
+			break
+		}
+		if math.Abs(next-rate) < 1e-10 {
+			return next, true
+		}
+		rate = next
+	}
+
+	// 二分法
+	low, high := -0.9999, 10.0
+	lowValue, _ := npv(low)
+	highValue, _ := npv(high)
+	if lowValue*highValue > 0 {
+		return 0, false
+	}
+	for i := 0; i < 200; i++ {
+		mid := (low + high) / 2
+		midValue, _ := npv(mid)
+		if math.Abs(midValue) < 1e-7 {
+			return mid, true
+		}
+		if lowValue*midValue < 0 {
+			high = mid
+		} else {
+			low, lowValue = mid, midValue
+		}
+	}
+	return (low + high) / 2, true
+}
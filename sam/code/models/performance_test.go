@@ -0,0 +1,47 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestCalcPerformance(t *testing.T) {
+	defer SetRepository(SetRepository(NewMemoryRepository()))
+	saveTestAssetMasterAndPrice(t)
+	saveTestStockTrade(t)
+	assetBuyList, err := GetAssetBuyByAssetCode("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	performanceList, err := CalcPerformance(assetBuyList, "", "", "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := performanceList.Total
+	if total.FromDate != "2021-09-21" || total.ToDate != "2021-09-24" {
+		t.Errorf("period = %s - %s, want 2021-09-21 - 2021-09-24", total.FromDate, total.ToDate)
+	}
+	// 時間加重収益率は売買のタイミングによらず株価の騰落率（1000円→1200円）と一致する
+	if total.Twr == nil || math.Abs(*total.Twr-0.2) > 1e-9 {
+		t.Errorf("Twr = %v, want 0.2", total.Twr)
+	}
+	if total.Xirr == nil || *total.Xirr <= 0 {
+		t.Errorf("Xirr = %v, want positive", total.Xirr)
+	}
+	if len(performanceList.Asset) != 1 || performanceList.Asset[0].Twr == nil || math.Abs(*performanceList.Asset[0].Twr-0.2) > 1e-9 {
+		t.Errorf("Asset = %+v, want 7203.T with Twr 0.2", performanceList.Asset)
+	}
+}
+
+// 期間の誤りは入力内容の誤りとする
+func TestCalcPerformanceInvalidPeriod(t *testing.T) {
+	defer SetRepository(SetRepository(NewMemoryRepository()))
+	var validationError *ValidationError
+	for _, period := range [][2]string{{"2021-09-31", ""}, {"", "yesterday"}, {"2021-09-24", "2021-09-21"}} {
+		if _, err := CalcPerformance(nil, period[0], period[1], "", 0); !errors.As(err, &validationError) {
+			t.Errorf("CalcPerformance(%s, %s) error = %v, want ValidationError", period[0], period[1], err)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"code/config"
+	"errors"
+	"sort"
+)
+
+/*
+ * 価格取得元のインターフェース
+ * 取得元を追加する場合は、本インターフェースを実装して RegisterPriceProvider で登録する
+ */
+type PriceProvider interface {
+	// 指定した資産の期間内（yyyy-mm-dd）の価格を取得
+	FetchPrice(assetMaster AssetMaster, fromDate string, toDate string) (FetchedPrice, error)
+}
+
+// 価格取得元から取得した価格データ
+type FetchedPrice struct {
+	// 価格の通貨（取得元から判別できない場合は空文字）
+	Currency       string
+	AssetDailyList []AssetDaily
+}
+
+// 取得元名をキーとした価格取得元の一覧
+var priceProviderList = map[string]PriceProvider{}
+
+/*
+ * 価格取得元を登録
+ */
+func RegisterPriceProvider(name string, provider PriceProvider) {
+	priceProviderList[name] = provider
+}
+
+/*
+ * 取得元名から価格取得元を取得
+ */
+func GetPriceProvider(name string) (PriceProvider, error) {
+	provider, ok := priceProviderList[name]
+	if !ok {
+		return nil, errors.New("unknown price provider: " + name)
+	}
+	return provider, nil
+}
+
+/*
+ * 登録済みの価格取得元名を名前順に取得
+ */
+func GetPriceProviderNameList() []string {
+	var nameList []string
+	for name := range priceProviderList {
+		nameList = append(nameList, name)
+	}
+	sort.Strings(nameList)
+	return nameList
+}
+
+/*
+ * 資産マスタに設定された価格取得元を取得
+ * 取得元が未設定の資産（取得元の設定追加前に登録された資産）は、資産タイプから既定の取得元を判定する
+ */
+func GetPriceProviderByAssetMaster(assetMaster AssetMaster) (PriceProvider, error) {
+	name := assetMaster.PriceProvider
+	if name == "" {
+		switch assetMaster.Type {
+		case config.ASSET_TYPE_STOCK, config.ASSET_TYPE_ETF, config.ASSET_TYPE_INDEX:
+			name = config.PRICE_PROVIDER_YAHOO_FINANCE
+		case config.ASSET_TYPE_INVESTMENT_TRUST:
+			name = config.PRICE_PROVIDER_SBI
+		default:
+			return nil, errors.New("no price provider for asset: " + assetMaster.AssetCode)
+		}
+	}
+	return GetPriceProvider(name)
+}
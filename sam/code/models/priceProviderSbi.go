@@ -0,0 +1,173 @@
+package models
+
+import (
+	"bytes"
+	"code/config"
+	"code/decimal"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/saintfish/chardet"
+	"golang.org/x/net/html/charset"
+)
+
+// SBI証券の基準価格履歴ページ
+const sbiPriceHistoryUrl = "https://site0.sbisec.co.jp/marble/fund/history/standardprice.do"
+
+// 投資信託の基準価格取得元（SBIからスクレイピングで取得）
+type SbiPriceProvider struct {
+	client  *http.Client
+	baseUrl string
+	// ページ取得の間隔
+	interval time.Duration
+}
+
+func init() {
+	RegisterPriceProvider(config.PRICE_PROVIDER_SBI, NewSbiPriceProvider(http.DefaultClient, sbiPriceHistoryUrl, time.Second*5))
+}
+
+/*
+ * SBI証券の価格取得元を生成
+ * テスト時は httptest のサーバーURLと間隔0を指定することで、ネットワークに接続せずに確認できる
+ */
+func NewSbiPriceProvider(client *http.Client, baseUrl string, interval time.Duration) *SbiPriceProvider {
+	return &SbiPriceProvider{client: client, baseUrl: baseUrl, interval: interval}
+}
+
+/*
+ * 投資信託の基準価格時系列データを取得
+ */
+func (provider *SbiPriceProvider) FetchPrice(assetMaster AssetMaster, fromDate string, toDate string) (FetchedPrice, error) {
+	// SBIの基準価格は円建て
+	fetchedPrice := FetchedPrice{Currency: config.CURRENCY_JPY}
+	// 日付設定
+	splitfromDate := strings.Split(fromDate, "-")
+	splitToDate := strings.Split(toDate, "-")
+	if len(splitfromDate) != 3 || len(splitToDate) != 3 {
+		return fetchedPrice, errors.New("invalid date format: " + fromDate + " - " + toDate)
+	}
+
+	// Post用のパラメータ設定
+	values := url.Values{}
+	values.Set("in_term_from_yyyy", splitfromDate[0])
+	values.Set("in_term_from_mm", splitfromDate[1])
+	values.Set("in_term_from_dd", splitfromDate[2])
+	values.Set("in_term_to_yyyy", splitToDate[0])
+	values.Set("in_term_to_mm", splitToDate[1])
+	values.Set("in_term_to_dd", splitToDate[2])
+	values.Set("dispRows", "100")
+	// データ1年毎に取得するため、ループは３回まで
+	for page := 0; page < 3; page++ {
+		values.Set("page", strconv.Itoa(page))
+		// 間隔を開けて取得する
+		if page > 0 {
+			time.Sleep(provider.interval)
+		}
+		// 基準価格取得
+		body, err := provider.request(assetMaster.AssetCode, values)
+		if err != nil {
+			return fetchedPrice, err
+		}
+		pageList, err := ParseSbiPriceHtml(assetMaster.AssetCode, body)
+		if err != nil {
+			return fetchedPrice, err
+		}
+		// 不要な接続を防ぐため、ループを抜ける
+		if len(pageList) == 0 {
+			break
+		}
+		fetchedPrice.AssetDailyList = append(fetchedPrice.AssetDailyList, pageList...)
+	}
+	return fetchedPrice, nil
+}
+
+// sbiのHPに接続し、基準価格ページのHTMLを取得
+func (provider *SbiPriceProvider) request(assetCode string, params url.Values) ([]byte, error) {
+	url := provider.baseUrl + "?fund_sec_code=" + assetCode
+
+	// リクエスト発行
+	req, err := http.NewRequest("POST", url, strings.NewReader(params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	// ヘッダー追加
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Add("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_13_5)” \"AppleWebKit/537.36 (KHTML, like Gecko) Chrome")
+
+	// リクエスト送信
+	resp, err := provider.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("sbi: " + resp.Status + ": " + assetCode)
+	}
+	return body, nil
+}
+
+/*
+ * SBIの基準価格ページのHTMLから基準価格を取得
+ */
+func ParseSbiPriceHtml(assetCode string, body []byte) ([]AssetDaily, error) {
+	var assetDailyList []AssetDaily
+	var dateList []string
+	var priceList []string
+
+	// 文字コード変換
+	det := chardet.NewTextDetector()
+	detRslt, err := det.DetectBest(body)
+	if err != nil {
+		return nil, err
+	}
+	bReader := bytes.NewReader(body)
+	reader, err := charset.NewReaderLabel(detRslt.Charset, bReader)
+	if err != nil {
+		return nil, err
+	}
+
+	// HTMLパース
+	htmlDoc, err := goquery.NewDocumentFromReader(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	// 基準価格日取得
+	htmlDate := htmlDoc.Find("div.alC")
+	htmlDate.Each(func(index int, s *goquery.Selection) {
+		date := strings.Replace(s.Text(), "/", "-", -1)
+		dateList = append(dateList, date)
+	})
+	// 基準価格取得
+	htmlPrice := htmlDoc.Find("div.alR")
+	htmlPrice.Each(func(index int, s *goquery.Selection) {
+		if index%3 == 0 {
+			tmpPrice := strings.Replace(s.Text(), "円", "", -1)
+			price := strings.Replace(tmpPrice, ",", "", -1)
+			priceList = append(priceList, price)
+		}
+	})
+
+	for idx, date := range dateList {
+		if idx >= len(priceList) {
+			break
+		}
+		price, err := decimal.NewFromString(priceList[idx])
+		if err != nil {
+			return nil, err
+		}
+		assetDailyList = append(assetDailyList, AssetDaily{AssetCode: assetCode, Date: date, Price: price})
+	}
+	return assetDailyList, nil
+}
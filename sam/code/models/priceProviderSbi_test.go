@@ -0,0 +1,119 @@
+package models
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// テスト用の資産マスタ（投資信託）
+var sbiTestAssetMaster = AssetMaster{AssetCode: "0331418A", CategoryId: "1", Type: 3, PriceProvider: "sbi"}
+
+// 記録済みのレスポンスを読み込む
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	body, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+// ページ番号毎に記録済みのHTMLを返すSBIのテスト用サーバー
+func newSbiTestServer(t *testing.T, pageList []string, requestCount *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requestCount++
+		if r.Method != "POST" {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		if code := r.URL.Query().Get("fund_sec_code"); code != sbiTestAssetMaster.AssetCode {
+			t.Errorf("fund_sec_code = %s", code)
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		for key, want := range map[string]string{"in_term_from_yyyy": "2021", "in_term_from_mm": "09", "in_term_from_dd": "01",
+			"in_term_to_yyyy": "2021", "in_term_to_mm": "09", "in_term_to_dd": "24", "dispRows": "100"} {
+			if got := r.PostForm.Get(key); got != want {
+				t.Errorf("%s = %s, want %s", key, got, want)
+			}
+		}
+		// 記録済みのページ数を超えた場合は最後のページを返す
+		page, _ := strconv.Atoi(r.PostForm.Get("page"))
+		if page >= len(pageList) {
+			page = len(pageList) - 1
+		}
+		w.Header().Set("Content-Type", "text/html; charset=Shift_JIS")
+		w.Write(readFixture(t, pageList[page]))
+	}))
+}
+
+func TestSbiPriceProviderFetchPrice(t *testing.T) {
+	requestCount := 0
+	server := newSbiTestServer(t, []string{"sbi_standardprice.html", "sbi_standardprice_empty.html"}, &requestCount)
+	defer server.Close()
+
+	provider := NewSbiPriceProvider(server.Client(), server.URL, 0)
+	fetchedPrice, err := provider.FetchPrice(sbiTestAssetMaster, "2021-09-01", "2021-09-24")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fetchedPrice.Currency != "JPY" {
+		t.Errorf("Currency = %s, want JPY", fetchedPrice.Currency)
+	}
+	want := []string{"2021-09-24:15524", "2021-09-22:15420", "2021-09-21:15608"}
+	if len(fetchedPrice.AssetDailyList) != len(want) {
+		t.Fatalf("AssetDailyList = %v", fetchedPrice.AssetDailyList)
+	}
+	for idx, data := range fetchedPrice.AssetDailyList {
+		if got := data.Date + ":" + data.Price.String(); got != want[idx] || data.AssetCode != sbiTestAssetMaster.AssetCode {
+			t.Errorf("AssetDailyList[%d] = %v, want %s", idx, data, want[idx])
+		}
+	}
+	// 空のページを取得した時点で終了する
+	if requestCount != 2 {
+		t.Errorf("request count = %d, want 2", requestCount)
+	}
+}
+
+func TestSbiPriceProviderFetchPriceEmpty(t *testing.T) {
+	requestCount := 0
+	server := newSbiTestServer(t, []string{"sbi_standardprice_empty.html"}, &requestCount)
+	defer server.Close()
+
+	provider := NewSbiPriceProvider(server.Client(), server.URL, 0)
+	fetchedPrice, err := provider.FetchPrice(sbiTestAssetMaster, "2021-09-01", "2021-09-24")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fetchedPrice.AssetDailyList) != 0 || requestCount != 1 {
+		t.Errorf("AssetDailyList = %v, request count = %d", fetchedPrice.AssetDailyList, requestCount)
+	}
+}
+
+func TestSbiPriceProviderFetchPriceError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	provider := NewSbiPriceProvider(server.Client(), server.URL, 0)
+	if _, err := provider.FetchPrice(sbiTestAssetMaster, "2021-09-01", "2021-09-24"); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("error = %v, want status 503", err)
+	}
+	if _, err := provider.FetchPrice(sbiTestAssetMaster, "20210901", "2021-09-24"); err == nil {
+		t.Errorf("invalid date: want error")
+	}
+}
+
+func TestParseSbiPriceHtmlInvalidPrice(t *testing.T) {
+	body := []byte(`<html><body><table><tr><td><div class="alC">2021/09/24</div></td>` +
+		`<td><div class="alR">--円</div></td><td><div class="alR">--円</div></td><td><div class="alR">--</div></td></tr></table></body></html>`)
+	if _, err := ParseSbiPriceHtml(sbiTestAssetMaster.AssetCode, body); err == nil {
+		t.Errorf("invalid price: want error")
+	}
+}
@@ -0,0 +1,181 @@
+package models
+
+import (
+	"code/config"
+	"code/decimal"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Yahoo Finance API(RapidAPI)のチャート取得エンドポイント
+const yahooFinanceChartUrl = "https://apidojo-yahoo-finance-v1.p.rapidapi.com/stock/v2/get-chart"
+
+// 株価の取得元（Yahoo Finance APIから取得）
+type YahooFinancePriceProvider struct {
+	client  *http.Client
+	baseUrl string
+	apiKey  string
+}
+
+type YahooFinanceStockData struct {
+	Chart struct {
+		Result []struct {
+			Meta struct {
+				Currency             string  `json:"currency"`
+				Symbol               string  `json:"symbol"`
+				ExchangeName         string  `json:"exchangeName"`
+				InstrumentType       string  `json:"instrumentType"`
+				FirstTradeDate       int     `json:"firstTradeDate"`
+				RegularMarketTime    int     `json:"regularMarketTime"`
+				Gmtoffset            int     `json:"gmtoffset"`
+				Timezone             string  `json:"timezone"`
+				ExchangeTimezoneName string  `json:"exchangeTimezoneName"`
+				RegularMarketPrice   float64 `json:"regularMarketPrice"`
+				ChartPreviousClose   float64 `json:"chartPreviousClose"`
+				PriceHint            int     `json:"priceHint"`
+				CurrentTradingPeriod struct {
+					Pre struct {
+						Timezone  string `json:"timezone"`
+						Start     int    `json:"start"`
+						End       int    `json:"end"`
+						Gmtoffset int    `json:"gmtoffset"`
+					} `json:"pre"`
+					Regular struct {
+						Timezone  string `json:"timezone"`
+						Start     int    `json:"start"`
+						End       int    `json:"end"`
+						Gmtoffset int    `json:"gmtoffset"`
+					} `json:"regular"`
+					Post struct {
+						Timezone  string `json:"timezone"`
+						Start     int    `json:"start"`
+						End       int    `json:"end"`
+						Gmtoffset int    `json:"gmtoffset"`
+					} `json:"post"`
+				} `json:"currentTradingPeriod"`
+				DataGranularity string   `json:"dataGranularity"`
+				Range           string   `json:"range"`
+				ValidRanges     []string `json:"validRanges"`
+			} `json:"meta"`
+			Timestamp  []int `json:"timestamp"`
+			Indicators struct {
+				Quote []struct {
+					Low    []float64 `json:"low"`
+					Close  []float64 `json:"close"`
+					Volume []float64 `json:"volume"`
+					Open   []float64 `json:"open"`
+					High   []float64 `json:"high"`
+				} `json:"quote"`
+				Adjclose []struct {
+					Adjclose []float64 `json:"adjclose"`
+				} `json:"adjclose"`
+			} `json:"indicators"`
+		} `json:"result"`
+		Error *struct {
+			Code        string `json:"code"`
+			Description string `json:"description"`
+		} `json:"error"`
+	} `json:"chart"`
+}
+
+func init() {
+	RegisterPriceProvider(config.PRICE_PROVIDER_YAHOO_FINANCE,
+		NewYahooFinancePriceProvider(http.DefaultClient, yahooFinanceChartUrl, os.Getenv("RAPIDAPI_Key")))
+}
+
+/*
+ * Yahoo Finance APIの価格取得元を生成
+ * テスト時は httptest のサーバーURLを指定することで、ネットワークに接続せずに確認できる
+ */
+func NewYahooFinancePriceProvider(client *http.Client, baseUrl string, apiKey string) *YahooFinancePriceProvider {
+	return &YahooFinancePriceProvider{client: client, baseUrl: baseUrl, apiKey: apiKey}
+}
+
+/*
+ * 株価の時系列データを取得
+ */
+func (provider *YahooFinancePriceProvider) FetchPrice(assetMaster AssetMaster, fromDate string, toDate string) (FetchedPrice, error) {
+	const layout = "2006-01-02"
+	from, err := time.Parse(layout, fromDate)
+	if err != nil {
+		return FetchedPrice{}, err
+	}
+	to, err := time.Parse(layout, toDate)
+	if err != nil {
+		return FetchedPrice{}, err
+	}
+
+	// 終了日の当日分まで含めるため、翌日0時を終了時刻とする
+	params := url.Values{}
+	params.Set("interval", "1d")
+	params.Set("symbol", assetMaster.AssetCode)
+	params.Set("period1", strconv.FormatInt(from.Unix(), 10))
+	params.Set("period2", strconv.FormatInt(to.AddDate(0, 0, 1).Unix(), 10))
+	params.Set("region", assetMaster.Region)
+	req, err := http.NewRequest("GET", provider.baseUrl+"?"+params.Encode(), nil)
+	if err != nil {
+		return FetchedPrice{}, err
+	}
+	req.Header.Add("x-rapidapi-key", provider.apiKey)
+	req.Header.Add("x-rapidapi-host", "apidojo-yahoo-finance-v1.p.rapidapi.com")
+	res, err := provider.client.Do(req)
+	if err != nil {
+		return FetchedPrice{}, err
+	}
+
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return FetchedPrice{}, err
+	}
+	// 認証エラーや利用制限の超過は、チャートデータ以外のJSONが返るためステータスで判定する
+	if res.StatusCode != http.StatusOK {
+		return FetchedPrice{}, errors.New("yahoo finance: " + res.Status + ": " + string(body))
+	}
+	return ParseYahooFinanceChart(assetMaster.AssetCode, body)
+}
+
+/*
+ * Yahoo Finance APIのチャートデータ(JSON)から株価と通貨を取得
+ */
+func ParseYahooFinanceChart(assetCode string, body []byte) (FetchedPrice, error) {
+	var fetchedPrice FetchedPrice
+	// JSONデコード
+	var yahooFinanceStockData YahooFinanceStockData
+	if err := json.Unmarshal(body, &yahooFinanceStockData); err != nil {
+		return fetchedPrice, err
+	}
+	// APIエラー判定
+	if apiErr := yahooFinanceStockData.Chart.Error; apiErr != nil {
+		return fetchedPrice, errors.New(apiErr.Code + ": " + apiErr.Description)
+	}
+	if len(yahooFinanceStockData.Chart.Result) == 0 || len(yahooFinanceStockData.Chart.Result[0].Indicators.Adjclose) == 0 {
+		return fetchedPrice, nil
+	}
+	fetchedPrice.Currency = yahooFinanceStockData.Chart.Result[0].Meta.Currency
+	// 日付、価格取得
+	gmtoffset := yahooFinanceStockData.Chart.Result[0].Meta.Gmtoffset
+	timestampList := yahooFinanceStockData.Chart.Result[0].Timestamp
+	adjcloseList := yahooFinanceStockData.Chart.Result[0].Indicators.Adjclose[0].Adjclose
+	const layout = "2006-01-02"
+	for idx, timestamp := range timestampList {
+		// 価格が欠損している日は対象外
+		if idx >= len(adjcloseList) || adjcloseList[idx] == 0 {
+			continue
+		}
+		// Unixタイムスタンプデータを取引所の現地時間のyyyy-mm-dd形式に変換
+		timeFull := time.Unix(int64(timestamp+gmtoffset), 0).UTC()
+		// 浮動小数点数の誤差を除くため、価格の桁数に丸める
+		price := RoundPrice(decimal.NewFromFloat(adjcloseList[idx]))
+		fetchedPrice.AssetDailyList = append(fetchedPrice.AssetDailyList,
+			AssetDaily{AssetCode: assetCode, Date: timeFull.Format(layout), Price: price})
+	}
+
+	return fetchedPrice, nil
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// テスト用の資産マスタ（株）
+var yahooTestAssetMaster = AssetMaster{AssetCode: "7203.T", CategoryId: "2", Type: 1, PriceProvider: "yahooFinance", Region: "JP"}
+
+// 記録済みのレスポンスを返すYahoo Finance APIのテスト用サーバー
+func newYahooTestServer(t *testing.T, statusCode int, fixture string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key := r.Header.Get("x-rapidapi-key"); key != "test-key" {
+			t.Errorf("x-rapidapi-key = %s", key)
+		}
+		// 期間は開始日0時から終了日翌日0時(UTC)まで
+		for key, want := range map[string]string{"interval": "1d", "symbol": "7203.T", "region": "JP",
+			"period1": "1632096000", "period2": "1632528000"} {
+			if got := r.URL.Query().Get(key); got != want {
+				t.Errorf("%s = %s, want %s", key, got, want)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		w.Write(readFixture(t, fixture))
+	}))
+}
+
+// テスト用サーバーから 2021-09-20〜2021-09-24 の価格を取得
+func fetchYahooTestPrice(t *testing.T, statusCode int, fixture string) (FetchedPrice, error) {
+	server := newYahooTestServer(t, statusCode, fixture)
+	defer server.Close()
+	provider := NewYahooFinancePriceProvider(server.Client(), server.URL, "test-key")
+	return provider.FetchPrice(yahooTestAssetMaster, "2021-09-20", "2021-09-24")
+}
+
+func TestYahooFinancePriceProviderFetchPrice(t *testing.T) {
+	fetchedPrice, err := fetchYahooTestPrice(t, http.StatusOK, "yahoo_chart.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fetchedPrice.Currency != "JPY" {
+		t.Errorf("Currency = %s, want JPY", fetchedPrice.Currency)
+	}
+	// 価格が欠損している日(null)は除き、取引所の現地時間の日付とする
+	want := []string{"2021-09-21:9912", "2021-09-24:10135"}
+	if len(fetchedPrice.AssetDailyList) != len(want) {
+		t.Fatalf("AssetDailyList = %v", fetchedPrice.AssetDailyList)
+	}
+	for idx, data := range fetchedPrice.AssetDailyList {
+		if got := data.Date + ":" + data.Price.String(); got != want[idx] || data.AssetCode != "7203.T" {
+			t.Errorf("AssetDailyList[%d] = %v, want %s", idx, data, want[idx])
+		}
+	}
+}
+
+func TestYahooFinancePriceProviderFetchPriceEmpty(t *testing.T) {
+	fetchedPrice, err := fetchYahooTestPrice(t, http.StatusOK, "yahoo_chart_empty.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fetchedPrice.Currency != "USD" || len(fetchedPrice.AssetDailyList) != 0 {
+		t.Errorf("FetchedPrice = %v", fetchedPrice)
+	}
+}
+
+func TestYahooFinancePriceProviderFetchPriceError(t *testing.T) {
+	cases := []struct {
+		statusCode int
+		fixture    string
+		want       string
+	}{
+		// チャートデータのエラー
+		{http.StatusNotFound, "yahoo_chart_error.json", "Not Found"},
+		{http.StatusOK, "yahoo_chart_error.json", "No data found"},
+		// 認証エラー（チャートデータ以外のJSON）
+		{http.StatusForbidden, "yahoo_unauthorized.json", "403"},
+		// JSON以外
+		{http.StatusOK, "sbi_standardprice_empty.html", "invalid character"},
+	}
+	for _, c := range cases {
+		if _, err := fetchYahooTestPrice(t, c.statusCode, c.fixture); err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%d %s: error = %v, want %s", c.statusCode, c.fixture, err, c.want)
+		}
+	}
+
+	provider := NewYahooFinancePriceProvider(http.DefaultClient, "http://127.0.0.1:0", "test-key")
+	if _, err := provider.FetchPrice(yahooTestAssetMaster, "2021/09/20", "2021-09-24"); err == nil {
+		t.Errorf("invalid date: want error")
+	}
+}
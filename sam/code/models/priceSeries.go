@@ -0,0 +1,55 @@
+package models
+
+import (
+	"code/decimal"
+	"sort"
+)
+
+// 日付順の価格の時系列（休日など価格がない日は直前の価格で補完する）
+type PriceSeries struct {
+	dateList  []string
+	priceList []decimal.Decimal
+}
+
+/*
+ * 価格データから価格の時系列を生成
+ */
+func NewPriceSeries(assetDailyList []AssetDaily) PriceSeries {
+	sortedList := make([]AssetDaily, len(assetDailyList))
+	copy(sortedList, assetDailyList)
+	sort.Slice(sortedList, func(i, j int) bool { return sortedList[i].Date < sortedList[j].Date })
+
+	var series PriceSeries
+	for _, data := range sortedList {
+		series.dateList = append(series.dateList, data.Date)
+		series.priceList = append(series.priceList, data.Price)
+	}
+	return series
+}
+
+/*
+ * 指定日の価格（指定日の価格がない場合は直前の価格）
+ * 指定日以前の価格がない場合は false を返す
+ */
+func (series PriceSeries) PriceAt(date string) (decimal.Decimal, bool) {
+	// 指定日より後の最初の位置
+	idx := sort.Search(len(series.dateList), func(i int) bool { return series.dateList[i] > date })
+	if idx == 0 {
+		return decimal.Zero, false
+	}
+	return series.priceList[idx-1], true
+}
+
+/*
+ * 価格がある日付の一覧
+ */
+func (series PriceSeries) DateList() []string {
+	return series.dateList
+}
+
+/*
+ * 価格データの件数
+ */
+func (series PriceSeries) Len() int {
+	return len(series.dateList)
+}
@@ -0,0 +1,339 @@
+package models
+
+import (
+	"code/config"
+	"code/decimal"
+	"sort"
+	"time"
+)
+
+// 積立プラン
+type PurchasePlan struct {
+	AssetCode string
+	PlanId    string
+	// 1回あたりの購入金額
+	Amount decimal.Decimal
+	// 頻度（daily, monthly）
+	Schedule string
+	// 毎月の購入日（monthly の場合のみ。月末より後の日は月末として扱う）
+	DayOfMonth int
+	StartDate  string
+	// 終了日（空文字の場合は無期限）
+	EndDate string
+	// 最後に購入を登録した日
+	LastExecutedDate string
+	// 停止中（停止中の購入日は登録しない）
+	Suspended bool
+}
+type PurchasePlanReq struct {
+	AssetCode  string          `json:"AssetCode"`
+	Amount     decimal.Decimal `json:"Amount"`
+	Schedule   string          `json:"Schedule"`
+	DayOfMonth int             `json:"DayOfMonth"`
+	StartDate  string          `json:"StartDate"`
+	EndDate    string          `json:"EndDate"`
+}
+
+// 積立プランの変更内容（指定された項目のみ変更する）
+type PurchasePlanPatchReq struct {
+	Amount     *decimal.Decimal `json:"Amount"`
+	DayOfMonth *int             `json:"DayOfMonth"`
+	// 空文字を指定した場合は無期限とする
+	EndDate   *string `json:"EndDate"`
+	Suspended *bool   `json:"Suspended"`
+}
+
+// 積立プラン毎の実行結果
+type PlanExecutionResult struct {
+	PlanId    string
+	AssetCode string
+	// 注文を登録した日（約定日の価格が未公表の場合は未約定の注文として登録）
+	DateList []string
+	Error    string
+}
+
+// 積立プランの実行結果
+type PlanExecutionSummary struct {
+	StartedAt    string
+	FinishedAt   string
+	ToDate       string
+	ExecuteCount int
+	FailureCount int
+	Results      []PlanExecutionResult
+}
+
+/*
+ * 積立プランの一覧を取得（資産コードの指定がない場合は全件）
+ */
+func GetPurchasePlanList(assetCode string) ([]PurchasePlan, error) {
+	return repository.PurchasePlan.FindByAssetCode(assetCode)
+}
+
+/*
+ * 積立プランを登録
+ */
+func SavePurchasePlan(purchasePlanReq *PurchasePlanReq) (PurchasePlan, error) {
+	purchasePlan := PurchasePlan{AssetCode: purchasePlanReq.AssetCode, Amount: purchasePlanReq.Amount,
+		Schedule: purchasePlanReq.Schedule, DayOfMonth: purchasePlanReq.DayOfMonth,
+		StartDate: purchasePlanReq.StartDate, EndDate: purchasePlanReq.EndDate}
+	if err := validatePurchasePlan(purchasePlan); err != nil {
+		return purchasePlan, err
+	}
+
+	if _, err := findTradableAssetMaster(purchasePlan.AssetCode); err != nil {
+		return purchasePlan, err
+	}
+
+	planId, err := NewPlanId()
+	if err != nil {
+		return purchasePlan, err
+	}
+	purchasePlan.PlanId = planId
+
+	err = repository.PurchasePlan.Save(purchasePlan)
+	return purchasePlan, err
+}
+
+/*
+ * 積立プランの購入金額・購入日・終了日の変更、停止・再開
+ * 再開した場合は停止中の購入日を登録しないよう、前回実行日を再開日（date）の前日とする
+ */
+func PatchPurchasePlan(assetCode string, planId string, purchasePlanPatchReq *PurchasePlanPatchReq, date string) (PurchasePlan, error) {
+	purchasePlan, err := findPurchasePlan(assetCode, planId)
+	if err != nil {
+		return purchasePlan, err
+	}
+	current := purchasePlan
+	if purchasePlanPatchReq.Amount != nil {
+		purchasePlan.Amount = *purchasePlanPatchReq.Amount
+	}
+	if purchasePlanPatchReq.DayOfMonth != nil {
+		purchasePlan.DayOfMonth = *purchasePlanPatchReq.DayOfMonth
+	}
+	if purchasePlanPatchReq.EndDate != nil {
+		purchasePlan.EndDate = *purchasePlanPatchReq.EndDate
+	}
+	if purchasePlanPatchReq.Suspended != nil {
+		purchasePlan.Suspended = *purchasePlanPatchReq.Suspended
+	}
+	if err := validatePurchasePlan(purchasePlan); err != nil {
+		return current, err
+	}
+	if current.Suspended && !purchasePlan.Suspended {
+		resumeDate, err := time.Parse(dateLayout, date)
+		if err != nil {
+			return current, newValidationError("invalid resume date: " + date)
+		}
+		if lastExecutedDate := resumeDate.AddDate(0, 0, -1).Format(dateLayout); lastExecutedDate > purchasePlan.LastExecutedDate {
+			purchasePlan.LastExecutedDate = lastExecutedDate
+		}
+	}
+
+	err = repository.PurchasePlan.Save(purchasePlan)
+	return purchasePlan, err
+}
+
+/*
+ * 積立プランを削除（プランから登録済みの取引・未約定の注文は削除しない）
+ */
+func DeletePurchasePlan(assetCode string, planId string) error {
+	if _, err := findPurchasePlan(assetCode, planId); err != nil {
+		return err
+	}
+	return repository.PurchasePlan.Delete(assetCode, planId)
+}
+
+/*
+ * 全ての積立プランについて、前回実行日の翌日から指定日までの購入日の取引を登録
+ * 購入日の基準価格で口数を算出するため、価格が未取得の購入日は次回以降の実行で登録する
+ * 1プランの登録に失敗しても他のプランの登録は継続し、結果を実行結果にまとめる
+ * 停止中のプランは登録しない
+ */
+func ExecuteAllPurchasePlan(toDate string) (PlanExecutionSummary, error) {
+	summary := PlanExecutionSummary{StartedAt: time.Now().Format(time.RFC3339), ToDate: toDate}
+	if _, err := time.Parse(dateLayout, toDate); err != nil {
+		return summary, err
+	}
+	purchasePlanList, err := GetPurchasePlanList("")
+	if err != nil {
+		return summary, err
+	}
+	sort.Slice(purchasePlanList, func(i, j int) bool {
+		return purchasePlanList[i].PlanId < purchasePlanList[j].PlanId
+	})
+
+	for _, purchasePlan := range purchasePlanList {
+		if purchasePlan.Suspended {
+			continue
+		}
+		result := executePurchasePlan(purchasePlan, toDate)
+		if result.Error != "" {
+			summary.FailureCount++
+		}
+		if len(result.DateList) > 0 || result.Error != "" {
+			summary.ExecuteCount = summary.ExecuteCount + len(result.DateList)
+			summary.Results = append(summary.Results, result)
+		}
+	}
+	summary.FinishedAt = time.Now().Format(time.RFC3339)
+	return summary, nil
+}
+
+// 1プランの未実行の購入日の取引を登録
+func executePurchasePlan(purchasePlan PurchasePlan, toDate string) PlanExecutionResult {
+	result := PlanExecutionResult{PlanId: purchasePlan.PlanId, AssetCode: purchasePlan.AssetCode}
+
+	// 前回実行日の翌日（未実行の場合は開始日）から、終了日と指定日の早い方までを対象とする
+	fromDate := purchasePlan.StartDate
+	if purchasePlan.LastExecutedDate >= fromDate {
+		lastExecuted, _ := time.Parse(dateLayout, purchasePlan.LastExecutedDate)
+		fromDate = lastExecuted.AddDate(0, 0, 1).Format(dateLayout)
+	}
+	if purchasePlan.EndDate != "" && purchasePlan.EndDate < toDate {
+		toDate = purchasePlan.EndDate
+	}
+	if fromDate > toDate {
+		return result
+	}
+
+	// 価格のある日を営業日として購入日を決める
+	priceList, err := GetAssetPriceByAssetCodeAndDate(purchasePlan.AssetCode, fromDate, toDate)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	var priceDateList []string
+	for _, price := range priceList {
+		priceDateList = append(priceDateList, price.Date)
+	}
+	sort.Strings(priceDateList)
+
+	// 登録済みの取引・未約定の注文（前回実行日の更新に失敗した場合の二重登録を防ぐ）
+	assetBuyList, err := GetAssetBuyByAssetCode(purchasePlan.AssetCode)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	assetOrderList, err := GetAssetOrderList(purchasePlan.AssetCode)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	executedDateMap := make(map[string]bool)
+	for _, data := range assetBuyList {
+		if data.PlanId == purchasePlan.PlanId {
+			executedDateMap[data.OrderDate] = true
+		}
+	}
+	for _, data := range assetOrderList {
+		if data.PlanId == purchasePlan.PlanId {
+			executedDateMap[data.OrderDate] = true
+		}
+	}
+
+	for _, date := range planExecutionDateList(purchasePlan, fromDate, priceDateList) {
+		if !executedDateMap[date] {
+			_, err := SaveAssetBuy(&AssetBuyReq{AssetCode: purchasePlan.AssetCode, Date: date,
+				TradeType: config.TRADE_TYPE_BUY, Amount: purchasePlan.Amount, PlanId: purchasePlan.PlanId})
+			if err != nil {
+				result.Error = date + ": " + err.Error()
+				return result
+			}
+			result.DateList = append(result.DateList, date)
+		}
+		// 実行日毎に更新し、途中で失敗しても次回は続きから登録する
+		if err := repository.PurchasePlan.UpdateLastExecutedDate(purchasePlan, date); err != nil {
+			result.Error = err.Error()
+			return result
+		}
+	}
+	return result
+}
+
+// 対象期間の購入日（日付順の価格のある日から、頻度に応じて抽出する）
+func planExecutionDateList(purchasePlan PurchasePlan, fromDate string, priceDateList []string) []string {
+	if purchasePlan.Schedule == config.PLAN_SCHEDULE_DAILY {
+		return priceDateList
+	}
+
+	// 毎月: 各月の購入指定日以降で最初に価格のある日（指定日が休場日の場合は翌営業日）
+	var dateList []string
+	if len(priceDateList) == 0 {
+		return dateList
+	}
+	from, _ := time.Parse(dateLayout, fromDate)
+	month := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+	idx := 0
+	for {
+		// 月末より後の日は月末とする
+		day := purchasePlan.DayOfMonth
+		if lastDay := month.AddDate(0, 1, -1).Day(); day > lastDay {
+			day = lastDay
+		}
+		scheduledDate := time.Date(month.Year(), month.Month(), day, 0, 0, 0, 0, time.UTC).Format(dateLayout)
+		month = month.AddDate(0, 1, 0)
+		if scheduledDate > priceDateList[len(priceDateList)-1] {
+			break
+		}
+		// 対象期間より前の購入指定日（開始日前または実行済み）は対象外
+		if scheduledDate < fromDate {
+			continue
+		}
+		for idx < len(priceDateList) && priceDateList[idx] < scheduledDate {
+			idx++
+		}
+		if idx < len(priceDateList) {
+			dateList = append(dateList, priceDateList[idx])
+			idx++
+		}
+	}
+	return dateList
+}
+
+// 資産コードとプランIDで積立プランを特定
+func findPurchasePlan(assetCode string, planId string) (PurchasePlan, error) {
+	if assetCode == "" || planId == "" {
+		return PurchasePlan{}, newValidationError("asset code and plan id are required")
+	}
+	purchasePlanList, err := GetPurchasePlanList(assetCode)
+	if err != nil {
+		return PurchasePlan{}, err
+	}
+	for _, data := range purchasePlanList {
+		if data.PlanId == planId {
+			return data, nil
+		}
+	}
+	return PurchasePlan{}, newValidationError("purchase plan not found: " + planId)
+}
+
+// 積立プランの入力内容を確認
+func validatePurchasePlan(purchasePlan PurchasePlan) error {
+	if purchasePlan.AssetCode == "" {
+		return newValidationError("asset code is required")
+	}
+	if purchasePlan.Amount.Sign() <= 0 {
+		return newValidationError("amount must be positive")
+	}
+	switch purchasePlan.Schedule {
+	case config.PLAN_SCHEDULE_DAILY:
+	case config.PLAN_SCHEDULE_MONTHLY:
+		if purchasePlan.DayOfMonth < 1 || purchasePlan.DayOfMonth > 31 {
+			return newValidationError("day of month must be between 1 and 31")
+		}
+	default:
+		return newValidationError("invalid schedule: " + purchasePlan.Schedule)
+	}
+	if _, err := time.Parse(dateLayout, purchasePlan.StartDate); err != nil {
+		return newValidationError("invalid start date: " + purchasePlan.StartDate)
+	}
+	if purchasePlan.EndDate != "" {
+		if _, err := time.Parse(dateLayout, purchasePlan.EndDate); err != nil {
+			return newValidationError("invalid end date: " + purchasePlan.EndDate)
+		}
+		if purchasePlan.EndDate < purchasePlan.StartDate {
+			return newValidationError("end date is before start date")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package models
+
+import (
+	"code/config"
+	"errors"
+	"testing"
+)
+
+// 前回実行日の翌日以降の購入日のみ登録し、再実行しても二重に登録しない
+func TestExecuteAllPurchasePlan(t *testing.T) {
+	defer SetRepository(SetRepository(NewMemoryRepository()))
+	saveTestAssetMasterAndPrice(t)
+
+	purchasePlan, err := SavePurchasePlan(&PurchasePlanReq{AssetCode: "7203.T", Amount: mustDecimal(t, "11000"),
+		Schedule: config.PLAN_SCHEDULE_DAILY, StartDate: "2021-09-22"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := ExecuteAllPurchasePlan("2021-09-24")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.ExecuteCount != 2 || summary.FailureCount != 0 {
+		t.Fatalf("ExecuteAllPurchasePlan = %+v, want 2 executions", summary)
+	}
+	if dateList := summary.Results[0].DateList; len(dateList) != 2 || dateList[0] != "2021-09-22" || dateList[1] != "2021-09-24" {
+		t.Errorf("DateList = %v, want 2021-09-22, 2021-09-24", dateList)
+	}
+
+	purchasePlanList, err := GetPurchasePlanList("7203.T")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(purchasePlanList) != 1 || purchasePlanList[0].PlanId != purchasePlan.PlanId || purchasePlanList[0].LastExecutedDate != "2021-09-24" {
+		t.Errorf("GetPurchasePlanList = %+v, want last executed 2021-09-24", purchasePlanList)
+	}
+	assetBuyList, err := GetAssetBuyByAssetCode("7203.T")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 11000円 / 1100円 = 10株、11000円 / 1200円 = 9.1667株
+	if len(assetBuyList) != 2 || assetBuyList[0].Unit.String() != "10" || assetBuyList[1].Unit.String() != "9.1667" ||
+		assetBuyList[0].PlanId != purchasePlan.PlanId {
+		t.Errorf("asset buy = %+v", assetBuyList)
+	}
+
+	summary, err = ExecuteAllPurchasePlan("2021-09-24")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.ExecuteCount != 0 {
+		t.Errorf("re-execution = %+v, want no execution", summary)
+	}
+}
+
+// 積立プランの入力内容の誤りは入力内容の誤りとする
+func TestSavePurchasePlanValidationError(t *testing.T) {
+	defer SetRepository(SetRepository(NewMemoryRepository()))
+	saveTestAssetMasterAndPrice(t)
+	if err := repository.AssetMaster.Save(AssetMaster{AssetCode: "^GSPC", CategoryId: config.INDEX_CATEGORY_ID, Type: config.ASSET_TYPE_INDEX}); err != nil {
+		t.Fatal(err)
+	}
+
+	valid := PurchasePlanReq{AssetCode: "FUND", Amount: mustDecimal(t, "10000"), Schedule: config.PLAN_SCHEDULE_MONTHLY,
+		DayOfMonth: 1, StartDate: "2021-10-01"}
+	invalidList := []func(req *PurchasePlanReq){
+		func(req *PurchasePlanReq) { req.AssetCode = "" },
+		func(req *PurchasePlanReq) { req.AssetCode = "UNKNOWN" },
+		func(req *PurchasePlanReq) { req.AssetCode = "^GSPC" },
+		func(req *PurchasePlanReq) { req.Amount = mustDecimal(t, "0") },
+		func(req *PurchasePlanReq) { req.Schedule = "weekly" },
+		func(req *PurchasePlanReq) { req.DayOfMonth = 32 },
+		func(req *PurchasePlanReq) { req.StartDate = "2021/10/01" },
+		func(req *PurchasePlanReq) { req.EndDate = "2021-09-30" },
+	}
+	var validationError *ValidationError
+	for idx, invalid := range invalidList {
+		req := valid
+		invalid(&req)
+		if _, err := SavePurchasePlan(&req); !errors.As(err, &validationError) {
+			t.Errorf("case %d: SavePurchasePlan(%+v) error = %v, want ValidationError", idx, req, err)
+		}
+	}
+	if purchasePlanList, _ := GetPurchasePlanList(""); len(purchasePlanList) != 0 {
+		t.Errorf("purchase plan = %+v, want none", purchasePlanList)
+	}
+}
+
+// 停止中のプランは登録せず、再開した場合は停止中の購入日を登録しない
+func TestPatchPurchasePlanSuspend(t *testing.T) {
+	defer SetRepository(SetRepository(NewMemoryRepository()))
+	saveTestAssetMasterAndPrice(t)
+
+	purchasePlan, err := SavePurchasePlan(&PurchasePlanReq{AssetCode: "7203.T", Amount: mustDecimal(t, "11000"),
+		Schedule: config.PLAN_SCHEDULE_DAILY, StartDate: "2021-09-21"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	suspended := true
+	if _, err := PatchPurchasePlan("7203.T", purchasePlan.PlanId, &PurchasePlanPatchReq{Suspended: &suspended}, "2021-09-21"); err != nil {
+		t.Fatal(err)
+	}
+	summary, err := ExecuteAllPurchasePlan("2021-09-22")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.ExecuteCount != 0 {
+		t.Errorf("suspended = %+v, want no execution", summary)
+	}
+
+	suspended = false
+	resumed, err := PatchPurchasePlan("7203.T", purchasePlan.PlanId, &PurchasePlanPatchReq{Suspended: &suspended}, "2021-09-24")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumed.Suspended || resumed.LastExecutedDate != "2021-09-23" {
+		t.Errorf("PatchPurchasePlan = %+v, want resumed with last executed 2021-09-23", resumed)
+	}
+	summary, err = ExecuteAllPurchasePlan("2021-09-24")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.ExecuteCount != 1 || summary.Results[0].DateList[0] != "2021-09-24" {
+		t.Errorf("resumed = %+v, want 2021-09-24 only", summary)
+	}
+}
+
+// 削除したプランは実行せず、登録済みの取引は残す
+func TestDeletePurchasePlan(t *testing.T) {
+	defer SetRepository(SetRepository(NewMemoryRepository()))
+	saveTestAssetMasterAndPrice(t)
+
+	purchasePlan, err := SavePurchasePlan(&PurchasePlanReq{AssetCode: "7203.T", Amount: mustDecimal(t, "11000"),
+		Schedule: config.PLAN_SCHEDULE_DAILY, StartDate: "2021-09-22"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ExecuteAllPurchasePlan("2021-09-22"); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeletePurchasePlan("7203.T", purchasePlan.PlanId); err != nil {
+		t.Fatal(err)
+	}
+	summary, err := ExecuteAllPurchasePlan("2021-09-24")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.ExecuteCount != 0 {
+		t.Errorf("deleted = %+v, want no execution", summary)
+	}
+	assetBuyList, err := GetAssetBuyByAssetCode("7203.T")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(assetBuyList) != 1 {
+		t.Errorf("asset buy = %+v, want the trade registered before deletion", assetBuyList)
+	}
+
+	var validationError *ValidationError
+	if err := DeletePurchasePlan("7203.T", purchasePlan.PlanId); !errors.As(err, &validationError) {
+		t.Errorf("DeletePurchasePlan(deleted) = %v, want ValidationError", err)
+	}
+}
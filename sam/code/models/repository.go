@@ -0,0 +1,100 @@
+package models
+
+// 資産マスタの保存先
+type AssetMasterRepository interface {
+	// 資産コードに一致する資産マスタ（カテゴリーID順）
+	FindByAssetCode(assetCode string) ([]AssetMaster, error)
+	// 資産コードとカテゴリーIDに一致する資産マスタ
+	FindByAssetCodeAndCategoryId(assetCode string, categoryId string) ([]AssetMaster, error)
+	// カテゴリーIDに一致する資産マスタ（資産コード順）
+	FindByCategoryId(categoryId string) ([]AssetMaster, error)
+	FindAll() ([]AssetMaster, error)
+	Save(assetMaster AssetMaster) error
+	Delete(assetCode string, categoryId string) error
+	// 登録済みの資産マスタを置き換える（カテゴリーIDが変わる場合は、登録と変更前のデータの削除をまとめて行う）
+	Replace(current AssetMaster, assetMaster AssetMaster) error
+	UpdateCurrency(assetMaster AssetMaster, currency string) error
+}
+
+// 価格の保存先
+type AssetPriceRepository interface {
+	// 資産コードに一致する価格（日付順。fromDate, toDate を指定した場合は期間で絞り込む）
+	FindByAssetCode(assetCode string, fromDate string, toDate string) ([]AssetDaily, error)
+	// 日付に一致する全資産の価格（資産コード順）
+	FindByDate(date string) ([]AssetDaily, error)
+	// 最新の価格の日付（価格がない場合は空文字）
+	FindLatestDate(assetCode string) (string, error)
+	Save(assetDaily AssetDaily) error
+	// 複数の価格をまとめて書き込む（先頭から書き込みが完了した件数を返す）
+	SaveList(assetDailyList []AssetDaily) (int, error)
+}
+
+// 取引データの保存先
+type AssetBuyRepository interface {
+	// 資産コードに一致する取引（取引ID順。資産コードが空文字の場合は全件）
+	FindByAssetCode(assetCode string) ([]AssetBuy, error)
+	// 約定日に一致する全資産の取引（取引ID順）
+	FindByDate(date string) ([]AssetBuy, error)
+	Save(assetBuy AssetBuy) error
+	Delete(assetCode string, transactionId string) error
+}
+
+// 未約定の注文の保存先
+type AssetOrderRepository interface {
+	// 資産コードに一致する注文（注文ID順。資産コードが空文字の場合は全件）
+	FindByAssetCode(assetCode string) ([]AssetOrder, error)
+	Save(assetOrder AssetOrder) error
+	Delete(assetCode string, transactionId string) error
+}
+
+// 配当金・分配金の保存先
+type AssetDistributionRepository interface {
+	// 資産コードに一致する配当金・分配金（取引ID順。資産コードが空文字の場合は全件）
+	FindByAssetCode(assetCode string) ([]AssetDistribution, error)
+	Save(assetDistribution AssetDistribution) error
+	Delete(assetCode string, transactionId string) error
+}
+
+// 目標配分の保存先
+type AllocationTargetRepository interface {
+	// 全ての目標配分（種別・コード順）
+	FindAll() ([]AllocationTarget, error)
+	Save(allocationTarget AllocationTarget) error
+	Delete(targetType string, code string) error
+}
+
+// 積立プランの保存先
+type PurchasePlanRepository interface {
+	// 資産コードに一致する積立プラン（プランID順。資産コードが空文字の場合は全件）
+	FindByAssetCode(assetCode string) ([]PurchasePlan, error)
+	Save(purchasePlan PurchasePlan) error
+	// 前回実行日のみを更新
+	UpdateLastExecutedDate(purchasePlan PurchasePlan, date string) error
+	Delete(assetCode string, planId string) error
+}
+
+/*
+ * モデルが使用するデータの保存先
+ */
+type Repository struct {
+	AssetMaster       AssetMasterRepository
+	AssetPrice        AssetPriceRepository
+	AssetBuy          AssetBuyRepository
+	AssetOrder        AssetOrderRepository
+	AssetDistribution AssetDistributionRepository
+	AllocationTarget  AllocationTargetRepository
+	PurchasePlan      PurchasePlanRepository
+}
+
+// モデルが使用する保存先（初期値はDynamodb）
+var repository = NewDynamoRepository()
+
+/*
+ * モデルが使用する保存先を差し替える（テストでメモリ上の保存先を使う場合など）
+ * 差し替える前の保存先を返す
+ */
+func SetRepository(newRepository Repository) Repository {
+	previous := repository
+	repository = newRepository
+	return previous
+}
@@ -0,0 +1,262 @@
+package models
+
+import "github.com/guregu/dynamo"
+
+// カテゴリーIDをパーティションキーとする資産マスタのGSI
+const assetMasterCategoryIdIndex = "CategoryId-index"
+
+// 日付をパーティションキーとする価格・取引のGSI
+const dateIndex = "Date-index"
+
+type dynamoAssetMasterRepository struct {
+	table dynamo.Table
+}
+
+type dynamoAssetPriceRepository struct {
+	table dynamo.Table
+}
+
+type dynamoAssetBuyRepository struct {
+	table dynamo.Table
+}
+
+type dynamoAssetOrderRepository struct {
+	table dynamo.Table
+}
+
+type dynamoAssetDistributionRepository struct {
+	table dynamo.Table
+}
+
+type dynamoAllocationTargetRepository struct {
+	table dynamo.Table
+}
+
+type dynamoPurchasePlanRepository struct {
+	table dynamo.Table
+}
+
+/*
+ * Dynamodbを保存先とする
+ */
+func NewDynamoRepository() Repository {
+	return Repository{
+		AssetMaster:       dynamoAssetMasterRepository{table: connectDynamodb("asset_master")},
+		AssetPrice:        dynamoAssetPriceRepository{table: connectDynamodb("asset_daily")},
+		AssetBuy:          dynamoAssetBuyRepository{table: connectDynamodb("asset_unit")},
+		AssetOrder:        dynamoAssetOrderRepository{table: connectDynamodb("asset_order")},
+		AssetDistribution: dynamoAssetDistributionRepository{table: connectDynamodb("asset_distribution")},
+		AllocationTarget:  dynamoAllocationTargetRepository{table: connectDynamodb("asset_target")},
+		PurchasePlan:      dynamoPurchasePlanRepository{table: connectDynamodb("asset_plan")},
+	}
+}
+
+func (repo dynamoAssetMasterRepository) FindByAssetCode(assetCode string) ([]AssetMaster, error) {
+	var assetMasterList []AssetMaster
+	err := repo.table.Get("AssetCode", assetCode).All(&assetMasterList)
+	return assetMasterList, err
+}
+
+func (repo dynamoAssetMasterRepository) FindByAssetCodeAndCategoryId(assetCode string, categoryId string) ([]AssetMaster, error) {
+	var assetMasterList []AssetMaster
+	err := repo.table.Get("AssetCode", assetCode).Range("CategoryId", dynamo.Equal, categoryId).All(&assetMasterList)
+	return assetMasterList, err
+}
+
+func (repo dynamoAssetMasterRepository) FindByCategoryId(categoryId string) ([]AssetMaster, error) {
+	var assetMasterList []AssetMaster
+	err := repo.table.Get("CategoryId", categoryId).Index(assetMasterCategoryIdIndex).All(&assetMasterList)
+	return assetMasterList, err
+}
+
+func (repo dynamoAssetMasterRepository) FindAll() ([]AssetMaster, error) {
+	var assetMasterList []AssetMaster
+	err := repo.table.Scan().All(&assetMasterList)
+	return assetMasterList, err
+}
+
+func (repo dynamoAssetMasterRepository) Save(assetMaster AssetMaster) error {
+	return repo.table.Put(assetMaster).Run()
+}
+
+func (repo dynamoAssetMasterRepository) Delete(assetCode string, categoryId string) error {
+	return repo.table.Delete("AssetCode", assetCode).Range("CategoryId", categoryId).Run()
+}
+
+func (repo dynamoAssetMasterRepository) Replace(current AssetMaster, assetMaster AssetMaster) error {
+	if current.AssetCode == assetMaster.AssetCode && current.CategoryId == assetMaster.CategoryId {
+		return repo.Save(assetMaster)
+	}
+	// キーが変わる場合は、片方のみ反映されないようトランザクションで書き込む
+	return dynamodb.WriteTx().
+		Put(repo.table.Put(assetMaster)).
+		Delete(repo.table.Delete("AssetCode", current.AssetCode).Range("CategoryId", current.CategoryId)).
+		Run()
+}
+
+func (repo dynamoAssetMasterRepository) UpdateCurrency(assetMaster AssetMaster, currency string) error {
+	return repo.table.Update("AssetCode", assetMaster.AssetCode).Range("CategoryId", assetMaster.CategoryId).
+		Set("Currency", currency).Run()
+}
+
+func (repo dynamoAssetPriceRepository) FindByAssetCode(assetCode string, fromDate string, toDate string) ([]AssetDaily, error) {
+	var assetDailyList []AssetDaily
+	query := repo.table.Get("AssetCode", assetCode)
+	// 期間はソートキーの条件として指定する
+	switch {
+	case fromDate != "" && toDate != "":
+		query = query.Range("Date", dynamo.Between, fromDate, toDate)
+	case fromDate != "":
+		query = query.Range("Date", dynamo.GreaterOrEqual, fromDate)
+	case toDate != "":
+		query = query.Range("Date", dynamo.LessOrEqual, toDate)
+	}
+	err := query.All(&assetDailyList)
+	return assetDailyList, err
+}
+
+func (repo dynamoAssetPriceRepository) FindByDate(date string) ([]AssetDaily, error) {
+	var assetDailyList []AssetDaily
+	err := repo.table.Get("Date", date).Index(dateIndex).All(&assetDailyList)
+	return assetDailyList, err
+}
+
+func (repo dynamoAssetPriceRepository) FindLatestDate(assetCode string) (string, error) {
+	var assetDailyList []AssetDaily
+	err := repo.table.Get("AssetCode", assetCode).Order(false).Limit(1).All(&assetDailyList)
+	if err != nil || len(assetDailyList) == 0 {
+		return "", err
+	}
+	return assetDailyList[len(assetDailyList)-1].Date, nil
+}
+
+func (repo dynamoAssetPriceRepository) Save(assetDaily AssetDaily) error {
+	return repo.table.Put(assetDaily).Run()
+}
+
+// BatchWriteItem の1回あたりの最大件数
+const batchWriteSize = 25
+
+// BatchWriteItem で25件ずつ書き込む
+// 未処理の項目の再送とスループット超過時の待機（指数バックオフ）は guregu/dynamo の BatchWrite が行う
+// 件数の多い書き込みでも時間切れにならないよう、タイムアウトは25件ごとに設定する
+// 戻り値は先頭から書き込みが完了した件数（失敗した25件の途中までの書き込みは含まない）
+func (repo dynamoAssetPriceRepository) SaveList(assetDailyList []AssetDaily) (int, error) {
+	wrote := 0
+	for start := 0; start < len(assetDailyList); start += batchWriteSize {
+		end := start + batchWriteSize
+		if end > len(assetDailyList) {
+			end = len(assetDailyList)
+		}
+		itemList := make([]interface{}, 0, end-start)
+		for _, assetDaily := range assetDailyList[start:end] {
+			itemList = append(itemList, assetDaily)
+		}
+		if _, err := repo.table.Batch("AssetCode", "Date").Write().Put(itemList...).Run(); err != nil {
+			return wrote, err
+		}
+		wrote = end
+	}
+	return wrote, nil
+}
+
+func (repo dynamoAssetBuyRepository) FindByAssetCode(assetCode string) ([]AssetBuy, error) {
+	var assetBuyList []AssetBuy
+	var err error
+	if assetCode != "" {
+		err = repo.table.Get("AssetCode", assetCode).All(&assetBuyList)
+	} else {
+		err = repo.table.Scan().All(&assetBuyList)
+	}
+	return assetBuyList, err
+}
+
+func (repo dynamoAssetBuyRepository) FindByDate(date string) ([]AssetBuy, error) {
+	var assetBuyList []AssetBuy
+	err := repo.table.Get("Date", date).Index(dateIndex).All(&assetBuyList)
+	return assetBuyList, err
+}
+
+func (repo dynamoAssetBuyRepository) Save(assetBuy AssetBuy) error {
+	return repo.table.Put(assetBuy).Run()
+}
+
+func (repo dynamoAssetBuyRepository) Delete(assetCode string, transactionId string) error {
+	return repo.table.Delete("AssetCode", assetCode).Range("TransactionId", transactionId).Run()
+}
+
+func (repo dynamoAssetOrderRepository) FindByAssetCode(assetCode string) ([]AssetOrder, error) {
+	var assetOrderList []AssetOrder
+	var err error
+	if assetCode != "" {
+		err = repo.table.Get("AssetCode", assetCode).All(&assetOrderList)
+	} else {
+		err = repo.table.Scan().All(&assetOrderList)
+	}
+	return assetOrderList, err
+}
+
+func (repo dynamoAssetOrderRepository) Save(assetOrder AssetOrder) error {
+	return repo.table.Put(assetOrder).Run()
+}
+
+func (repo dynamoAssetOrderRepository) Delete(assetCode string, transactionId string) error {
+	return repo.table.Delete("AssetCode", assetCode).Range("TransactionId", transactionId).Run()
+}
+
+func (repo dynamoAssetDistributionRepository) FindByAssetCode(assetCode string) ([]AssetDistribution, error) {
+	var assetDistributionList []AssetDistribution
+	var err error
+	if assetCode != "" {
+		err = repo.table.Get("AssetCode", assetCode).All(&assetDistributionList)
+	} else {
+		err = repo.table.Scan().All(&assetDistributionList)
+	}
+	return assetDistributionList, err
+}
+
+func (repo dynamoAssetDistributionRepository) Save(assetDistribution AssetDistribution) error {
+	return repo.table.Put(assetDistribution).Run()
+}
+
+func (repo dynamoAssetDistributionRepository) Delete(assetCode string, transactionId string) error {
+	return repo.table.Delete("AssetCode", assetCode).Range("TransactionId", transactionId).Run()
+}
+
+func (repo dynamoAllocationTargetRepository) FindAll() ([]AllocationTarget, error) {
+	var allocationTargetList []AllocationTarget
+	err := repo.table.Scan().All(&allocationTargetList)
+	return allocationTargetList, err
+}
+
+func (repo dynamoAllocationTargetRepository) Save(allocationTarget AllocationTarget) error {
+	return repo.table.Put(allocationTarget).Run()
+}
+
+func (repo dynamoAllocationTargetRepository) Delete(targetType string, code string) error {
+	return repo.table.Delete("TargetType", targetType).Range("Code", code).Run()
+}
+
+func (repo dynamoPurchasePlanRepository) FindByAssetCode(assetCode string) ([]PurchasePlan, error) {
+	var purchasePlanList []PurchasePlan
+	var err error
+	if assetCode != "" {
+		err = repo.table.Get("AssetCode", assetCode).All(&purchasePlanList)
+	} else {
+		err = repo.table.Scan().All(&purchasePlanList)
+	}
+	return purchasePlanList, err
+}
+
+func (repo dynamoPurchasePlanRepository) Save(purchasePlan PurchasePlan) error {
+	return repo.table.Put(purchasePlan).Run()
+}
+
+func (repo dynamoPurchasePlanRepository) UpdateLastExecutedDate(purchasePlan PurchasePlan, date string) error {
+	return repo.table.Update("AssetCode", purchasePlan.AssetCode).Range("PlanId", purchasePlan.PlanId).
+		Set("LastExecutedDate", date).Run()
+}
+
+func (repo dynamoPurchasePlanRepository) Delete(assetCode string, planId string) error {
+	return repo.table.Delete("AssetCode", assetCode).Range("PlanId", planId).Run()
+}
@@ -0,0 +1,437 @@
+package models
+
+import (
+	"sort"
+	"sync"
+)
+
+// メモリ上の保存先で共有するロック
+type memoryStore struct {
+	mutex *sync.Mutex
+}
+
+type memoryAssetMasterRepository struct {
+	memoryStore
+	// 資産コード→カテゴリーID→資産マスタ
+	data map[string]map[string]AssetMaster
+}
+
+type memoryAssetPriceRepository struct {
+	memoryStore
+	// 資産コード→日付→価格
+	data map[string]map[string]AssetDaily
+}
+
+type memoryAssetBuyRepository struct {
+	memoryStore
+	// 資産コード→取引ID→取引
+	data map[string]map[string]AssetBuy
+}
+
+type memoryAssetOrderRepository struct {
+	memoryStore
+	// 資産コード→注文ID→注文
+	data map[string]map[string]AssetOrder
+}
+
+type memoryAssetDistributionRepository struct {
+	memoryStore
+	// 資産コード→取引ID→配当金・分配金
+	data map[string]map[string]AssetDistribution
+}
+
+type memoryAllocationTargetRepository struct {
+	memoryStore
+	// 種別→コード→目標配分
+	data map[string]map[string]AllocationTarget
+}
+
+type memoryPurchasePlanRepository struct {
+	memoryStore
+	// 資産コード→プランID→積立プラン
+	data map[string]map[string]PurchasePlan
+}
+
+/*
+ * メモリ上を保存先とする（テスト用。Dynamodbと同じくキーの順に返す）
+ */
+func NewMemoryRepository() Repository {
+	store := memoryStore{mutex: &sync.Mutex{}}
+	return Repository{
+		AssetMaster:       memoryAssetMasterRepository{memoryStore: store, data: map[string]map[string]AssetMaster{}},
+		AssetPrice:        memoryAssetPriceRepository{memoryStore: store, data: map[string]map[string]AssetDaily{}},
+		AssetBuy:          memoryAssetBuyRepository{memoryStore: store, data: map[string]map[string]AssetBuy{}},
+		AssetOrder:        memoryAssetOrderRepository{memoryStore: store, data: map[string]map[string]AssetOrder{}},
+		AssetDistribution: memoryAssetDistributionRepository{memoryStore: store, data: map[string]map[string]AssetDistribution{}},
+		AllocationTarget:  memoryAllocationTargetRepository{memoryStore: store, data: map[string]map[string]AllocationTarget{}},
+		PurchasePlan:      memoryPurchasePlanRepository{memoryStore: store, data: map[string]map[string]PurchasePlan{}},
+	}
+}
+
+// 指定したパーティションキー（空文字の場合は全て）のソートキーを、パーティションキー・ソートキー順に列挙
+func sortedMemoryKeyList(rangeKeyListByHashKey map[string][]string, hashKey string) [][2]string {
+	var hashKeyList []string
+	for key := range rangeKeyListByHashKey {
+		if hashKey == "" || key == hashKey {
+			hashKeyList = append(hashKeyList, key)
+		}
+	}
+	sort.Strings(hashKeyList)
+	var keyList [][2]string
+	for _, key := range hashKeyList {
+		rangeKeyList := rangeKeyListByHashKey[key]
+		sort.Strings(rangeKeyList)
+		for _, rangeKey := range rangeKeyList {
+			keyList = append(keyList, [2]string{key, rangeKey})
+		}
+	}
+	return keyList
+}
+
+func (repo memoryAssetMasterRepository) FindByAssetCode(assetCode string) ([]AssetMaster, error) {
+	if assetCode == "" {
+		return nil, nil
+	}
+	return repo.find(assetCode), nil
+}
+
+func (repo memoryAssetMasterRepository) FindByAssetCodeAndCategoryId(assetCode string, categoryId string) ([]AssetMaster, error) {
+	var assetMasterList []AssetMaster
+	for _, assetMaster := range repo.find(assetCode) {
+		if assetCode != "" && assetMaster.CategoryId == categoryId {
+			assetMasterList = append(assetMasterList, assetMaster)
+		}
+	}
+	return assetMasterList, nil
+}
+
+func (repo memoryAssetMasterRepository) FindByCategoryId(categoryId string) ([]AssetMaster, error) {
+	var assetMasterList []AssetMaster
+	for _, assetMaster := range repo.find("") {
+		if assetMaster.CategoryId == categoryId {
+			assetMasterList = append(assetMasterList, assetMaster)
+		}
+	}
+	return assetMasterList, nil
+}
+
+func (repo memoryAssetMasterRepository) FindAll() ([]AssetMaster, error) {
+	return repo.find(""), nil
+}
+
+func (repo memoryAssetMasterRepository) find(assetCode string) []AssetMaster {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	keyListByAssetCode := make(map[string][]string)
+	for code, dataByCategoryId := range repo.data {
+		for categoryId := range dataByCategoryId {
+			keyListByAssetCode[code] = append(keyListByAssetCode[code], categoryId)
+		}
+	}
+	var assetMasterList []AssetMaster
+	for _, key := range sortedMemoryKeyList(keyListByAssetCode, assetCode) {
+		assetMasterList = append(assetMasterList, repo.data[key[0]][key[1]])
+	}
+	return assetMasterList
+}
+
+func (repo memoryAssetMasterRepository) Save(assetMaster AssetMaster) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	if repo.data[assetMaster.AssetCode] == nil {
+		repo.data[assetMaster.AssetCode] = map[string]AssetMaster{}
+	}
+	repo.data[assetMaster.AssetCode][assetMaster.CategoryId] = assetMaster
+	return nil
+}
+
+func (repo memoryAssetMasterRepository) Delete(assetCode string, categoryId string) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	delete(repo.data[assetCode], categoryId)
+	return nil
+}
+
+func (repo memoryAssetMasterRepository) Replace(current AssetMaster, assetMaster AssetMaster) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	delete(repo.data[current.AssetCode], current.CategoryId)
+	if repo.data[assetMaster.AssetCode] == nil {
+		repo.data[assetMaster.AssetCode] = map[string]AssetMaster{}
+	}
+	repo.data[assetMaster.AssetCode][assetMaster.CategoryId] = assetMaster
+	return nil
+}
+
+func (repo memoryAssetMasterRepository) UpdateCurrency(assetMaster AssetMaster, currency string) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	data := repo.data[assetMaster.AssetCode][assetMaster.CategoryId]
+	// Dynamodbの更新と同じく、未登録の場合はキーのみのデータを作成する
+	data.AssetCode = assetMaster.AssetCode
+	data.CategoryId = assetMaster.CategoryId
+	data.Currency = currency
+	if repo.data[assetMaster.AssetCode] == nil {
+		repo.data[assetMaster.AssetCode] = map[string]AssetMaster{}
+	}
+	repo.data[assetMaster.AssetCode][assetMaster.CategoryId] = data
+	return nil
+}
+
+func (repo memoryAssetPriceRepository) FindByAssetCode(assetCode string, fromDate string, toDate string) ([]AssetDaily, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	var assetDailyList []AssetDaily
+	if assetCode == "" {
+		return assetDailyList, nil
+	}
+	for _, data := range repo.data[assetCode] {
+		if (fromDate != "" && data.Date < fromDate) || (toDate != "" && data.Date > toDate) {
+			continue
+		}
+		assetDailyList = append(assetDailyList, data)
+	}
+	sort.Slice(assetDailyList, func(i, j int) bool {
+		return assetDailyList[i].Date < assetDailyList[j].Date
+	})
+	return assetDailyList, nil
+}
+
+func (repo memoryAssetPriceRepository) FindByDate(date string) ([]AssetDaily, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	var assetDailyList []AssetDaily
+	for _, dataByDate := range repo.data {
+		if data, ok := dataByDate[date]; ok {
+			assetDailyList = append(assetDailyList, data)
+		}
+	}
+	sort.Slice(assetDailyList, func(i, j int) bool {
+		return assetDailyList[i].AssetCode < assetDailyList[j].AssetCode
+	})
+	return assetDailyList, nil
+}
+
+func (repo memoryAssetPriceRepository) FindLatestDate(assetCode string) (string, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	latestDate := ""
+	for date := range repo.data[assetCode] {
+		if date > latestDate {
+			latestDate = date
+		}
+	}
+	return latestDate, nil
+}
+
+func (repo memoryAssetPriceRepository) Save(assetDaily AssetDaily) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	if repo.data[assetDaily.AssetCode] == nil {
+		repo.data[assetDaily.AssetCode] = map[string]AssetDaily{}
+	}
+	repo.data[assetDaily.AssetCode][assetDaily.Date] = assetDaily
+	return nil
+}
+
+func (repo memoryAssetPriceRepository) SaveList(assetDailyList []AssetDaily) (int, error) {
+	for _, assetDaily := range assetDailyList {
+		repo.Save(assetDaily)
+	}
+	return len(assetDailyList), nil
+}
+
+func (repo memoryAssetBuyRepository) FindByAssetCode(assetCode string) ([]AssetBuy, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	keyListByAssetCode := make(map[string][]string)
+	for code, dataByTransactionId := range repo.data {
+		for transactionId := range dataByTransactionId {
+			keyListByAssetCode[code] = append(keyListByAssetCode[code], transactionId)
+		}
+	}
+	var assetBuyList []AssetBuy
+	for _, key := range sortedMemoryKeyList(keyListByAssetCode, assetCode) {
+		assetBuyList = append(assetBuyList, repo.data[key[0]][key[1]])
+	}
+	return assetBuyList, nil
+}
+
+func (repo memoryAssetBuyRepository) FindByDate(date string) ([]AssetBuy, error) {
+	assetBuyList, _ := repo.FindByAssetCode("")
+	var assetBuyListByDate []AssetBuy
+	for _, data := range assetBuyList {
+		if data.Date == date {
+			assetBuyListByDate = append(assetBuyListByDate, data)
+		}
+	}
+	sort.Slice(assetBuyListByDate, func(i, j int) bool {
+		return assetBuyListByDate[i].TransactionId < assetBuyListByDate[j].TransactionId
+	})
+	return assetBuyListByDate, nil
+}
+
+func (repo memoryAssetBuyRepository) Save(assetBuy AssetBuy) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	if repo.data[assetBuy.AssetCode] == nil {
+		repo.data[assetBuy.AssetCode] = map[string]AssetBuy{}
+	}
+	repo.data[assetBuy.AssetCode][assetBuy.TransactionId] = assetBuy
+	return nil
+}
+
+func (repo memoryAssetBuyRepository) Delete(assetCode string, transactionId string) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	delete(repo.data[assetCode], transactionId)
+	return nil
+}
+
+func (repo memoryAssetOrderRepository) FindByAssetCode(assetCode string) ([]AssetOrder, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	keyListByAssetCode := make(map[string][]string)
+	for code, dataByTransactionId := range repo.data {
+		for transactionId := range dataByTransactionId {
+			keyListByAssetCode[code] = append(keyListByAssetCode[code], transactionId)
+		}
+	}
+	var assetOrderList []AssetOrder
+	for _, key := range sortedMemoryKeyList(keyListByAssetCode, assetCode) {
+		assetOrderList = append(assetOrderList, repo.data[key[0]][key[1]])
+	}
+	return assetOrderList, nil
+}
+
+func (repo memoryAssetOrderRepository) Save(assetOrder AssetOrder) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	if repo.data[assetOrder.AssetCode] == nil {
+		repo.data[assetOrder.AssetCode] = map[string]AssetOrder{}
+	}
+	repo.data[assetOrder.AssetCode][assetOrder.TransactionId] = assetOrder
+	return nil
+}
+
+func (repo memoryAssetOrderRepository) Delete(assetCode string, transactionId string) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	delete(repo.data[assetCode], transactionId)
+	return nil
+}
+
+func (repo memoryAssetDistributionRepository) FindByAssetCode(assetCode string) ([]AssetDistribution, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	keyListByAssetCode := make(map[string][]string)
+	for code, dataByTransactionId := range repo.data {
+		for transactionId := range dataByTransactionId {
+			keyListByAssetCode[code] = append(keyListByAssetCode[code], transactionId)
+		}
+	}
+	var assetDistributionList []AssetDistribution
+	for _, key := range sortedMemoryKeyList(keyListByAssetCode, assetCode) {
+		assetDistributionList = append(assetDistributionList, repo.data[key[0]][key[1]])
+	}
+	return assetDistributionList, nil
+}
+
+func (repo memoryAssetDistributionRepository) Save(assetDistribution AssetDistribution) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	if repo.data[assetDistribution.AssetCode] == nil {
+		repo.data[assetDistribution.AssetCode] = map[string]AssetDistribution{}
+	}
+	repo.data[assetDistribution.AssetCode][assetDistribution.TransactionId] = assetDistribution
+	return nil
+}
+
+func (repo memoryAssetDistributionRepository) Delete(assetCode string, transactionId string) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	delete(repo.data[assetCode], transactionId)
+	return nil
+}
+
+func (repo memoryAllocationTargetRepository) FindAll() ([]AllocationTarget, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	keyListByTargetType := make(map[string][]string)
+	for targetType, dataByCode := range repo.data {
+		for code := range dataByCode {
+			keyListByTargetType[targetType] = append(keyListByTargetType[targetType], code)
+		}
+	}
+	var allocationTargetList []AllocationTarget
+	for _, key := range sortedMemoryKeyList(keyListByTargetType, "") {
+		allocationTargetList = append(allocationTargetList, repo.data[key[0]][key[1]])
+	}
+	return allocationTargetList, nil
+}
+
+func (repo memoryAllocationTargetRepository) Save(allocationTarget AllocationTarget) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	if repo.data[allocationTarget.TargetType] == nil {
+		repo.data[allocationTarget.TargetType] = map[string]AllocationTarget{}
+	}
+	repo.data[allocationTarget.TargetType][allocationTarget.Code] = allocationTarget
+	return nil
+}
+
+func (repo memoryAllocationTargetRepository) Delete(targetType string, code string) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	delete(repo.data[targetType], code)
+	return nil
+}
+
+func (repo memoryPurchasePlanRepository) FindByAssetCode(assetCode string) ([]PurchasePlan, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	keyListByAssetCode := make(map[string][]string)
+	for code, dataByPlanId := range repo.data {
+		for planId := range dataByPlanId {
+			keyListByAssetCode[code] = append(keyListByAssetCode[code], planId)
+		}
+	}
+	var purchasePlanList []PurchasePlan
+	for _, key := range sortedMemoryKeyList(keyListByAssetCode, assetCode) {
+		purchasePlanList = append(purchasePlanList, repo.data[key[0]][key[1]])
+	}
+	return purchasePlanList, nil
+}
+
+func (repo memoryPurchasePlanRepository) Save(purchasePlan PurchasePlan) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	if repo.data[purchasePlan.AssetCode] == nil {
+		repo.data[purchasePlan.AssetCode] = map[string]PurchasePlan{}
+	}
+	repo.data[purchasePlan.AssetCode][purchasePlan.PlanId] = purchasePlan
+	return nil
+}
+
+func (repo memoryPurchasePlanRepository) UpdateLastExecutedDate(purchasePlan PurchasePlan, date string) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	// Dynamodbの更新と同じく、未登録の場合はキーと前回実行日のみのデータを登録する
+	saved, ok := repo.data[purchasePlan.AssetCode][purchasePlan.PlanId]
+	if !ok {
+		if repo.data[purchasePlan.AssetCode] == nil {
+			repo.data[purchasePlan.AssetCode] = map[string]PurchasePlan{}
+		}
+		saved = PurchasePlan{AssetCode: purchasePlan.AssetCode, PlanId: purchasePlan.PlanId}
+	}
+	saved.LastExecutedDate = date
+	repo.data[purchasePlan.AssetCode][purchasePlan.PlanId] = saved
+	return nil
+}
+
+func (repo memoryPurchasePlanRepository) Delete(assetCode string, planId string) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	delete(repo.data[assetCode], planId)
+	return nil
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"code/config"
+	"code/decimal"
+	"testing"
+)
+
+// テスト用に文字列から生成（失敗した場合はテストを中断する）
+func mustDecimal(t *testing.T, s string) decimal.Decimal {
+	t.Helper()
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		t.Fatalf("decimal.NewFromString(%q): %v", s, err)
+	}
+	return d
+}
+
+// テスト用の資産マスタと価格を登録
+// 7203.T: 株（国内株）、FUND: 投資信託（先進国株・約定日オフセット1）
+func saveTestAssetMasterAndPrice(t *testing.T) {
+	t.Helper()
+	assetMasterList := []AssetMaster{
+		{AssetCode: "7203.T", CategoryId: "1", Name: "トヨタ自動車", Type: config.ASSET_TYPE_STOCK,
+			PriceProvider: config.PRICE_PROVIDER_YAHOO_FINANCE, Currency: config.CURRENCY_JPY},
+		{AssetCode: "FUND", CategoryId: "2", Name: "先進国株式ファンド", Type: config.ASSET_TYPE_INVESTMENT_TRUST,
+			PriceProvider: config.PRICE_PROVIDER_SBI, Currency: config.CURRENCY_JPY, NavDateOffset: 1},
+	}
+	for _, assetMaster := range assetMasterList {
+		if err := repository.AssetMaster.Save(assetMaster); err != nil {
+			t.Fatal(err)
+		}
+	}
+	priceList := []AssetDaily{
+		{AssetCode: "7203.T", Date: "2021-09-21", Price: mustDecimal(t, "1000")},
+		{AssetCode: "7203.T", Date: "2021-09-22", Price: mustDecimal(t, "1100")},
+		{AssetCode: "7203.T", Date: "2021-09-24", Price: mustDecimal(t, "1200")},
+		{AssetCode: "FUND", Date: "2021-09-21", Price: mustDecimal(t, "10000")},
+		{AssetCode: "FUND", Date: "2021-09-22", Price: mustDecimal(t, "10500")},
+	}
+	if _, err := repository.AssetPrice.SaveList(priceList); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// テスト用の取引を登録（失敗した場合はテストを中断する）
+func saveTestAssetBuy(t *testing.T, assetBuyReq AssetBuyReq) AssetBuyResult {
+	t.Helper()
+	result, err := SaveAssetBuy(&assetBuyReq)
+	if err != nil {
+		t.Fatalf("SaveAssetBuy(%+v): %v", assetBuyReq, err)
+	}
+	return result
+}
+
+// 7203.T を 09/21 と 09/22 に100株ずつ購入し、09/24 に100株売却する
+func saveTestStockTrade(t *testing.T) {
+	t.Helper()
+	saveTestAssetBuy(t, AssetBuyReq{AssetCode: "7203.T", Date: "2021-09-21", TradeType: config.TRADE_TYPE_BUY, Unit: decimal.NewFromInt(100)})
+	saveTestAssetBuy(t, AssetBuyReq{AssetCode: "7203.T", Date: "2021-09-22", TradeType: config.TRADE_TYPE_BUY, Unit: decimal.NewFromInt(100)})
+	saveTestAssetBuy(t, AssetBuyReq{AssetCode: "7203.T", Date: "2021-09-24", TradeType: config.TRADE_TYPE_SELL, Unit: decimal.NewFromInt(100)})
+}
+
+func TestMemoryAllocationTargetRepositoryOrder(t *testing.T) {
+	repo := NewMemoryRepository().AllocationTarget
+	for _, target := range []AllocationTarget{
+		{TargetType: config.ALLOCATION_TARGET_TYPE_CATEGORY, Code: "2", Weight: mustDecimal(t, "0.4")},
+		{TargetType: config.ALLOCATION_TARGET_TYPE_ASSET, Code: "7203.T", Weight: mustDecimal(t, "0.1")},
+		{TargetType: config.ALLOCATION_TARGET_TYPE_CATEGORY, Code: "1", Weight: mustDecimal(t, "0.6")},
+	} {
+		if err := repo.Save(target); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repo.Delete(config.ALLOCATION_TARGET_TYPE_ASSET, "7203.T"); err != nil {
+		t.Fatal(err)
+	}
+	targetList, err := repo.FindAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targetList) != 2 || targetList[0].Code != "1" || targetList[1].Code != "2" {
+		t.Errorf("FindAll() = %+v, want category 1, 2", targetList)
+	}
+}
@@ -0,0 +1,192 @@
+package models
+
+import "math"
+
+// 年率換算に使用する1年あたりの営業日数
+const tradingDaysPerYear = 252
+
+// リスク指標（算出できない場合は nil）
+type RiskMetrics struct {
+	// 資産コード（ポートフォリオの場合は空文字）
+	Code     string
+	Name     string
+	FromDate string
+	ToDate   string
+	// 年率ボラティリティ
+	Volatility *float64
+	// 年率リターン（日次リターンの平均を年率換算）
+	AnnualizedReturn *float64
+	// シャープレシオ
+	SharpeRatio *float64
+	// ソルティノレシオ
+	SortinoRatio *float64
+	// 最大ドローダウン（ピークからの下落率。下落がない場合は0）
+	MaxDrawdown *float64
+	// 最大ドローダウンのピーク日・ボトム日・回復日（未回復の場合は空文字）
+	PeakDate     string
+	TroughDate   string
+	RecoveryDate string
+}
+
+// ポートフォリオと資産毎のリスク指標
+type RiskMetricsList struct {
+	Portfolio RiskMetrics
+	Asset     []RiskMetrics
+}
+
+/*
+ * 保有資産の価格と、取引を再生したポートフォリオの評価額からリスク指標を算出
+ * fromDate, toDate(yyyy-mm-dd) で期間を指定する（指定がない場合は全期間）
+ * riskFreeRate は年率の無リスク金利（ex: 0.001）
+ * 資産毎の指標は基準通貨に換算した価格、ポートフォリオの指標は入出金を除いた時間加重の基準価額から算出する
+ */
+func CalcRiskMetrics(assetBuyList []AssetBuy, fromDate string, toDate string, riskFreeRate float64) (RiskMetricsList, error) {
+	var riskMetricsList RiskMetricsList
+	dateList, valuationList, err := loadPeriodValuationList(assetBuyList, fromDate, toDate)
+	if err != nil {
+		return riskMetricsList, err
+	}
+
+	// ポートフォリオ
+	valueList, flowList, start, err := groupValueFlowList(valuationList, dateList)
+	if err != nil {
+		return riskMetricsList, err
+	}
+	if start < len(dateList) {
+		riskMetricsList.Portfolio = calcRiskMetrics(dateList[start:], twrIndexList(valueList, flowList, start), riskFreeRate)
+	}
+
+	// 資産毎（各資産の価格がある日のみを使用する）
+	for _, valuation := range valuationList {
+		var assetDateList []string
+		var levelList []float64
+		for _, date := range valuation.priceSeries.DateList() {
+			if (fromDate != "" && date < fromDate) || (toDate != "" && date > toDate) {
+				continue
+			}
+			price, _ := valuation.priceSeries.PriceAt(date)
+			assetDateList = append(assetDateList, date)
+			levelList = append(levelList, price.Float64()*valuation.fxRateList.Rate(date).Float64())
+		}
+		riskMetrics := calcRiskMetrics(assetDateList, levelList, riskFreeRate)
+		riskMetrics.Code = valuation.assetMaster.AssetCode
+		riskMetrics.Name = valuation.assetMaster.Name
+		riskMetricsList.Asset = append(riskMetricsList.Asset, riskMetrics)
+	}
+	return riskMetricsList, nil
+}
+
+// 日付毎の水準（価格・基準価額）からリスク指標を算出
+func calcRiskMetrics(dateList []string, levelList []float64, riskFreeRate float64) RiskMetrics {
+	var riskMetrics RiskMetrics
+	if len(dateList) == 0 {
+		return riskMetrics
+	}
+	riskMetrics.FromDate = dateList[0]
+	riskMetrics.ToDate = dateList[len(dateList)-1]
+
+	// 日次リターン
+	returnList := levelReturnList(levelList)
+
+	// 最大ドローダウン
+	maxDrawdown, peak, trough, recovery := calcMaxDrawdown(levelList)
+	if maxDrawdown != nil {
+		riskMetrics.MaxDrawdown = maxDrawdown
+		riskMetrics.PeakDate = dateList[peak]
+		riskMetrics.TroughDate = dateList[trough]
+		if recovery >= 0 {
+			riskMetrics.RecoveryDate = dateList[recovery]
+		}
+	}
+
+	if len(returnList) < 2 {
+		return riskMetrics
+	}
+	mean, stdev := meanStdev(returnList)
+	dailyRiskFreeRate := riskFreeRate / tradingDaysPerYear
+
+	annualizedReturn := mean * tradingDaysPerYear
+	riskMetrics.AnnualizedReturn = &annualizedReturn
+	volatility := stdev * math.Sqrt(tradingDaysPerYear)
+	riskMetrics.Volatility = &volatility
+	if volatility > 0 {
+		sharpeRatio := (annualizedReturn - riskFreeRate) / volatility
+		riskMetrics.SharpeRatio = &sharpeRatio
+	}
+
+	// 下方偏差（無リスク金利を下回ったリターンのみを対象とする）
+	downsideSum := 0.0
+	for _, r := range returnList {
+		if excess := r - dailyRiskFreeRate; excess < 0 {
+			downsideSum = downsideSum + excess*excess
+		}
+	}
+	downsideDeviation := math.Sqrt(downsideSum/float64(len(returnList))) * math.Sqrt(tradingDaysPerYear)
+	if downsideDeviation > 0 {
+		sortinoRatio := (annualizedReturn - riskFreeRate) / downsideDeviation
+		riskMetrics.SortinoRatio = &sortinoRatio
+	}
+	return riskMetrics
+}
+
+// 水準の日次リターン（前日の水準が0以下の日は対象外）
+func levelReturnList(levelList []float64) []float64 {
+	var returnList []float64
+	for idx := 1; idx < len(levelList); idx++ {
+		if levelList[idx-1] <= 0 {
+			continue
+		}
+		returnList = append(returnList, levelList[idx]/levelList[idx-1]-1)
+	}
+	return returnList
+}
+
+// 最大ドローダウンとピーク・ボトム・回復の位置（回復していない場合は -1）
+func calcMaxDrawdown(levelList []float64) (*float64, int, int, int) {
+	if len(levelList) == 0 {
+		return nil, 0, 0, -1
+	}
+	maxDrawdown := 0.0
+	peak, trough, recovery := 0, 0, -1
+	currentPeak := 0
+	for idx, level := range levelList {
+		if level > levelList[currentPeak] {
+			currentPeak = idx
+		}
+		if levelList[currentPeak] <= 0 {
+			continue
+		}
+		drawdown := level/levelList[currentPeak] - 1
+		if drawdown < maxDrawdown {
+			maxDrawdown = drawdown
+			peak, trough = currentPeak, idx
+		}
+	}
+	// ボトム以降でピークの水準まで戻った最初の日
+	if maxDrawdown < 0 {
+		for idx := trough + 1; idx < len(levelList); idx++ {
+			if levelList[idx] >= levelList[peak] {
+				recovery = idx
+				break
+			}
+		}
+	}
+	return &maxDrawdown, peak, trough, recovery
+}
+
+// 平均と標本標準偏差
+func meanStdev(list []float64) (float64, float64) {
+	sum := 0.0
+	for _, v := range list {
+		sum = sum + v
+	}
+	mean := sum / float64(len(list))
+	if len(list) < 2 {
+		return mean, 0
+	}
+	squareSum := 0.0
+	for _, v := range list {
+		squareSum = squareSum + (v-mean)*(v-mean)
+	}
+	return mean, math.Sqrt(squareSum / float64(len(list)-1))
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"crypto/rand"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ULIDで使用するCrockford Base32の文字セット
+const crockfordBase32 = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+/*
+ * 取引IDを生成（"取引日#ULID"形式）
+ * 取引日を先頭に付与することで、ソートキーの並びが取引日順となり、日付の範囲指定でも取得できる
+ */
+func NewTransactionId(date string) (string, error) {
+	ulid, err := newUlid(time.Now())
+	if err != nil {
+		return "", err
+	}
+	return date + "#" + ulid, nil
+}
+
+/*
+ * 積立プランIDを生成（ULID。登録順に並ぶ）
+ */
+func NewPlanId() (string, error) {
+	return newUlid(time.Now())
+}
+
+// 同じミリ秒内で生成したULIDを単調増加させるため、直前に生成したタイムスタンプと乱数部を保持する
+var ulidState struct {
+	mutex   sync.Mutex
+	ms      uint64
+	entropy [10]byte
+}
+
+// ULID(48bitのミリ秒タイムスタンプ + 80bitの乱数)を26文字の文字列で生成
+// 直前と同じミリ秒（時計が戻った場合を含む）は直前の乱数部に1を加え、生成順に並ぶようにする（同一プロセス内のみ）
+func newUlid(now time.Time) (string, error) {
+	ulidState.mutex.Lock()
+	defer ulidState.mutex.Unlock()
+
+	ms := uint64(now.UnixNano() / int64(time.Millisecond))
+	if ms <= ulidState.ms {
+		ms = ulidState.ms
+		if !incrementUlidEntropy(&ulidState.entropy) {
+			return "", errors.New("ulid entropy overflow")
+		}
+	} else {
+		if _, err := rand.Read(ulidState.entropy[:]); err != nil {
+			return "", err
+		}
+		ulidState.ms = ms
+	}
+
+	var data [16]byte
+	for i := 0; i < 6; i++ {
+		data[i] = byte(ms >> uint(40-8*i))
+	}
+	copy(data[6:], ulidState.entropy[:])
+
+	// 128bitを先頭から5bitずつ区切って変換する（先頭2bitは0埋め）
+	var ulid [26]byte
+	for i := 0; i < 26; i++ {
+		bitPos := i*5 - 2
+		var value byte
+		for bit := 0; bit < 5; bit++ {
+			pos := bitPos + bit
+			value = value << 1
+			if pos >= 0 && data[pos/8]&(0x80>>uint(pos%8)) != 0 {
+				value = value | 1
+			}
+		}
+		ulid[i] = crockfordBase32[value]
+	}
+	return string(ulid[:]), nil
+}
+
+// 乱数部に1を加える（桁あふれした場合は false を返す）
+func incrementUlidEntropy(entropy *[10]byte) bool {
+	for i := len(entropy) - 1; i >= 0; i-- {
+		entropy[i]++
+		if entropy[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
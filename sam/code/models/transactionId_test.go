@@ -0,0 +1,39 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+// 同じミリ秒内に生成した取引IDも生成順に並ぶ
+func TestNewUlidMonotonic(t *testing.T) {
+	now := time.Now()
+	previous, err := newUlid(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		// 時計が戻った場合も直前のIDより後に並ぶ
+		ulid, err := newUlid(now.Add(-time.Duration(i%2) * time.Second))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ulid) != 26 || ulid <= previous {
+			t.Fatalf("newUlid = %s, want after %s", ulid, previous)
+		}
+		previous = ulid
+	}
+}
+
+func TestIncrementUlidEntropy(t *testing.T) {
+	entropy := [10]byte{9: 0xff}
+	if !incrementUlidEntropy(&entropy) || entropy[8] != 1 || entropy[9] != 0 {
+		t.Errorf("entropy = %v, want carry to the next byte", entropy)
+	}
+	for i := range entropy {
+		entropy[i] = 0xff
+	}
+	if incrementUlidEntropy(&entropy) {
+		t.Error("incrementUlidEntropy = true, want overflow")
+	}
+}
@@ -0,0 +1,17 @@
+package models
+
+/*
+ * 入力内容の誤りを表すエラー（APIではステータス400として返す）
+ */
+type ValidationError struct {
+	Message string
+}
+
+func (err *ValidationError) Error() string {
+	return err.Message
+}
+
+// 入力内容の誤りを表すエラーを生成
+func newValidationError(message string) error {
+	return &ValidationError{Message: message}
+}
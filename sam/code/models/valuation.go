@@ -0,0 +1,104 @@
+package models
+
+import (
+	"code/config"
+	"code/decimal"
+	"errors"
+)
+
+// 金額の小数点以下の桁数（通貨の補助単位まで。円は円未満を四捨五入）
+var currencyAmountPlaces = map[string]int{config.CURRENCY_JPY: 0}
+
+// 通貨毎の桁数が未定義の場合の金額の小数点以下の桁数（セント単位）
+const defaultAmountPlaces = 2
+
+// 価格の小数点以下の桁数
+const pricePlaces = 4
+
+// 投資信託の口数の小数点以下の桁数（1口未満は四捨五入）
+const investmentTrustUnitPlaces = 0
+
+// 株・ETFの株数の小数点以下の桁数（端株・米国株の小数点取引に対応）
+const stockUnitPlaces = 4
+
+/*
+ * 基準価格の単位となる口数を取得（投資信託は1万口あたりの基準価格）
+ */
+func BasePriceConstant(assetType int) decimal.Decimal {
+	if assetType == config.ASSET_TYPE_INVESTMENT_TRUST {
+		return decimal.NewFromInt(10000)
+	}
+	return decimal.NewFromInt(1)
+}
+
+/*
+ * 通貨に応じて金額を丸める
+ */
+func RoundAmount(amount decimal.Decimal, currency string) decimal.Decimal {
+	if places, ok := currencyAmountPlaces[currency]; ok {
+		return amount.Round(places)
+	}
+	return amount.Round(defaultAmountPlaces)
+}
+
+/*
+ * 価格を丸める
+ */
+func RoundPrice(price decimal.Decimal) decimal.Decimal {
+	return price.Round(pricePlaces)
+}
+
+/*
+ * 資産タイプに応じて口数を丸める
+ */
+func RoundUnit(unit decimal.Decimal, assetType int) decimal.Decimal {
+	switch assetType {
+	case config.ASSET_TYPE_STOCK, config.ASSET_TYPE_ETF:
+		return unit.Round(stockUnitPlaces)
+	case config.ASSET_TYPE_CACHE:
+		return unit.Round(defaultAmountPlaces)
+	}
+	return unit.Round(investmentTrustUnitPlaces)
+}
+
+/*
+ * 価格と口数から評価額（資産の通貨建て）を算出
+ */
+func CalcPresentValue(price decimal.Decimal, unit decimal.Decimal, assetMaster AssetMaster) (decimal.Decimal, error) {
+	value, err := price.Mul(unit)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	value, err = value.Div(BasePriceConstant(assetMaster.Type))
+	return RoundAmount(value, AssetCurrency(assetMaster)), err
+}
+
+/*
+ * 金額と価格から口数を算出（価格が0以下の場合はエラー）
+ */
+func CalcUnitByAmount(amount decimal.Decimal, price decimal.Decimal, assetMaster AssetMaster) (decimal.Decimal, error) {
+	if price.Sign() <= 0 {
+		return decimal.Zero, errors.New("invalid price " + price.String() + ": " + assetMaster.AssetCode)
+	}
+	unit, err := amount.Mul(BasePriceConstant(assetMaster.Type))
+	if err != nil {
+		return decimal.Zero, err
+	}
+	unit, err = unit.Div(price)
+	return RoundUnit(unit, assetMaster.Type), err
+}
+
+/*
+ * 取得金額と口数から平均取得単価を算出（口数が0の場合は0）
+ */
+func CalcAverageUnitPrice(amount decimal.Decimal, unit decimal.Decimal, assetMaster AssetMaster) (decimal.Decimal, error) {
+	if unit.IsZero() {
+		return decimal.Zero, nil
+	}
+	price, err := amount.Mul(BasePriceConstant(assetMaster.Type))
+	if err != nil {
+		return decimal.Zero, err
+	}
+	price, err = price.Div(unit)
+	return RoundPrice(price), err
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"code/models"
+	"flag"
+	"log"
+)
+
+/*
+ * asset_unit のキー変更（AssetCode + Date → AssetCode + TransactionId）に伴うデータ移行
+ * 1. 既存の asset_unit をバックアップし、別名のテーブル（例: asset_unit_legacy）へ復元する
+ * 2. asset_unit を削除し、schema/asset_unit.json（または template.yaml）の定義で再作成する
+ * 3. 本ツールを実行し、旧テーブルのデータに取引IDを採番して asset_unit へ登録する
+ *    go run ./tools/migrateAssetUnit -source asset_unit_legacy
+ */
+func main() {
+	source := flag.String("source", "asset_unit_legacy", "移行元のテーブル名")
+	flag.Parse()
+
+	count, err := models.MigrateAssetUnit(*source)
+	if err != nil {
+		log.Fatalf("migration failed after %d items: %v", count, err)
+	}
+	log.Printf("migrated %d items from %s to asset_unit", count, *source)
+}